@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// lineRefPattern matches explicit line references such as "line 42" or "第42行"
+var lineRefPattern = regexp.MustCompile(`(?i)\blines?\s*#?(\d+)|第\s*(\d+)\s*行`)
+
+// quotedSnippetPattern matches snippets the model quoted from the log
+var quotedSnippetPattern = regexp.MustCompile("`([^`\\n]{12,})`|\"([^\"\\n]{12,})\"")
+
+// findReferencedLines maps 1-based log line numbers to the findings that reference them
+// Explicit line numbers are taken as-is; quoted snippets map to the first log line containing them
+func findReferencedLines(result, logContent string) map[int][]string {
+	lines := strings.Split(logContent, "\n")
+	refs := make(map[int][]string)
+
+	add := func(lineNo int, note string) {
+		if lineNo < 1 || lineNo > len(lines) {
+			return
+		}
+		for _, existing := range refs[lineNo] {
+			if existing == note {
+				return
+			}
+		}
+		refs[lineNo] = append(refs[lineNo], note)
+	}
+
+	for _, m := range lineRefPattern.FindAllStringSubmatch(result, -1) {
+		numStr := m[1]
+		if numStr == "" {
+			numStr = m[2]
+		}
+		if n, err := strconv.Atoi(numStr); err == nil {
+			add(n, "referenced as line "+numStr)
+		}
+	}
+
+	for _, m := range quotedSnippetPattern.FindAllStringSubmatch(result, -1) {
+		snippet := strings.TrimSpace(m[1] + m[2])
+		for i, line := range lines {
+			if strings.Contains(line, snippet) {
+				add(i+1, "quoted in analysis")
+				break
+			}
+		}
+	}
+
+	return refs
+}
+
+// annotateLog returns the log with line numbers and a marker on each referenced line
+func annotateLog(logContent string, refs map[int][]string) string {
+	lines := strings.Split(logContent, "\n")
+	width := len(strconv.Itoa(len(lines)))
+
+	var sb strings.Builder
+	for i, line := range lines {
+		lineNo := i + 1
+		marker := "   "
+		if _, ok := refs[lineNo]; ok {
+			marker = ">> "
+		}
+		sb.WriteString(fmt.Sprintf("%s%*d | %s", marker, width, lineNo, line))
+		if notes, ok := refs[lineNo]; ok {
+			sb.WriteString("    <-- " + strings.Join(notes, "; "))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// writeAnnotatedLog writes the source log annotated with the result's references
+// It returns an empty path when the result references no log lines
+func writeAnnotatedLog(path, result, logContent string) (string, error) {
+	refs := findReferencedLines(result, logContent)
+	if len(refs) == 0 {
+		return "", nil
+	}
+
+	lineNos := make([]int, 0, len(refs))
+	for n := range refs {
+		lineNos = append(lineNos, n)
+	}
+	sort.Ints(lineNos)
+
+	var header strings.Builder
+	header.WriteString("# Source log annotated with lines referenced by the analysis\n")
+	header.WriteString(fmt.Sprintf("# Referenced lines: %s\n\n", joinInts(lineNos)))
+
+	content := header.String() + annotateLog(logContent, refs)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// joinInts formats integers as a comma-separated list
+func joinInts(nums []int) string {
+	parts := make([]string, len(nums))
+	for i, n := range nums {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const annotateTestLog = "INFO starting worker pool\nWARN slow query on orders\nERROR connection pool exhausted after 30s\nINFO shutting down"
+
+func TestFindReferencedLines(t *testing.T) {
+	tests := []struct {
+		name   string
+		result string
+		want   map[int]string
+	}{
+		{"explicit line", "See line 2 for the slow query.", map[int]string{2: "referenced as line 2"}},
+		{"chinese line", "问题出现在第4行", map[int]string{4: "referenced as line 4"}},
+		{"quoted snippet", "The log shows `connection pool exhausted` before shutdown.", map[int]string{3: "quoted in analysis"}},
+		{"out of range", "line 99 is not in the log", map[int]string{}},
+		{"short quote ignored", "the `pool` is fine", map[int]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findReferencedLines(tt.result, annotateTestLog)
+			if len(got) != len(tt.want) {
+				t.Fatalf("findReferencedLines = %v, want %v", got, tt.want)
+			}
+			for line, note := range tt.want {
+				if len(got[line]) != 1 || got[line][0] != note {
+					t.Errorf("line %d notes = %v, want [%s]", line, got[line], note)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteAnnotatedLog(t *testing.T) {
+	dir := t.TempDir()
+
+	path, err := writeAnnotatedLog(filepath.Join(dir, "none.log"), "Nothing specific.", annotateTestLog)
+	if err != nil || path != "" {
+		t.Fatalf("writeAnnotatedLog without references = %q, %v; want no file", path, err)
+	}
+
+	result := "Line 3 shows `connection pool exhausted after 30s`."
+	path, err = writeAnnotatedLog(filepath.Join(dir, "annotated.log"), result, annotateTestLog)
+	if err != nil || path == "" {
+		t.Fatalf("writeAnnotatedLog = %q, %v", path, err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(data)
+	if !strings.Contains(content, "# Referenced lines: 3\n") {
+		t.Errorf("header missing referenced lines:\n%s", content)
+	}
+	if !strings.Contains(content, ">> 3 | ERROR connection pool exhausted after 30s    <-- referenced as line 3; quoted in analysis") {
+		t.Errorf("line 3 not annotated:\n%s", content)
+	}
+	if !strings.Contains(content, "   1 | INFO starting worker pool\n") {
+		t.Errorf("unreferenced line not numbered:\n%s", content)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// archiveTruncatedMarker is appended when an archive exceeds the extraction limits
+const archiveTruncatedMarker = "\n[... truncated: archive exceeds size limit ...]\n"
+
+// isArchiveName reports whether a file name looks like a supported log archive
+func isArchiveName(name string) bool {
+	lower := strings.ToLower(name)
+	return strings.HasSuffix(lower, ".zip") ||
+		strings.HasSuffix(lower, ".tar") ||
+		strings.HasSuffix(lower, ".tar.gz") ||
+		strings.HasSuffix(lower, ".tgz")
+}
+
+// archiveCombiner builds a multi-source log from archive entries within limits
+type archiveCombiner struct {
+	maxEntries int
+	remaining  int64
+	entries    int
+	skipped    int
+	truncated  bool
+	out        strings.Builder
+}
+
+// add appends one archive entry as a section, skipping binary content
+// It returns false once no more entries should be read
+func (c *archiveCombiner) add(name string, r io.Reader) bool {
+	if c.entries >= c.maxEntries || c.remaining <= 0 {
+		c.truncated = true
+		return false
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, c.remaining+1))
+	if err != nil || isBinary(data) {
+		c.skipped++
+		return true
+	}
+
+	cut := int64(len(data)) > c.remaining
+	if cut {
+		data = data[:c.remaining]
+		c.truncated = true
+	}
+
+	c.out.WriteString(sectionHeader(name) + "\n")
+	c.out.Write(data)
+	if !strings.HasSuffix(string(data), "\n") {
+		c.out.WriteString("\n")
+	}
+	c.remaining -= int64(len(data))
+	c.entries++
+	return !cut
+}
+
+// result returns the combined log
+func (c *archiveCombiner) result() string {
+	if c.truncated {
+		return c.out.String() + archiveTruncatedMarker
+	}
+	return c.out.String()
+}
+
+// extractArchive combines the text entries of a zip or tar(.gz) archive into one log
+// Extraction is bounded by maxEntries and maxBytes of uncompressed text to prevent zip bombs
+func extractArchive(data []byte, name string, maxEntries int, maxBytes int64) (string, int, error) {
+	c := &archiveCombiner{maxEntries: maxEntries, remaining: maxBytes}
+
+	lower := strings.ToLower(name)
+	var err error
+	if strings.HasSuffix(lower, ".zip") {
+		err = extractZip(data, c)
+	} else {
+		err = extractTar(data, strings.HasSuffix(lower, ".gz") || strings.HasSuffix(lower, ".tgz"), c)
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	if c.entries == 0 {
+		return "", 0, fmt.Errorf("archive contains no text files")
+	}
+	return c.result(), c.entries, nil
+}
+
+// extractZip feeds zip entries to the combiner
+func extractZip(data []byte, c *archiveCombiner) error {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("invalid zip archive: %v", err)
+	}
+
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			c.skipped++
+			continue
+		}
+		more := c.add(path.Clean(f.Name), rc)
+		rc.Close()
+		if !more {
+			break
+		}
+	}
+	return nil
+}
+
+// extractTar feeds tar entries to the combiner
+func extractTar(data []byte, gzipped bool, c *archiveCombiner) error {
+	var r io.Reader = bytes.NewReader(data)
+	if gzipped {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return fmt.Errorf("invalid gzip archive: %v", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid tar archive: %v", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if !c.add(path.Clean(hdr.Name), tr) {
+			return nil
+		}
+	}
+}
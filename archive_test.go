@@ -0,0 +1,89 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+)
+
+// testArchiveFiles are the entries written into test archives, in order
+var testArchiveFiles = []struct {
+	name string
+	body string
+}{
+	{"logs/api.log", "ERROR api failed\n"},
+	{"bin/core.dump", "\x00\x01\x02\x03"},
+	{"logs/worker.log", "ERROR worker failed\n"},
+}
+
+func testZip(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range testArchiveFiles {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(f.body))
+	}
+	zw.Close()
+	return buf.Bytes()
+}
+
+func testTarGz(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, f := range testArchiveFiles {
+		tw.WriteHeader(&tar.Header{Name: f.name, Mode: 0644, Size: int64(len(f.body)), Typeflag: tar.TypeReg})
+		tw.Write([]byte(f.body))
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func TestExtractArchive(t *testing.T) {
+	archives := map[string][]byte{
+		"bundle.zip":    testZip(t),
+		"bundle.tar.gz": testTarGz(t),
+	}
+
+	want := "=== logs/api.log ===\nERROR api failed\n=== logs/worker.log ===\nERROR worker failed\n"
+	for name, data := range archives {
+		t.Run(name, func(t *testing.T) {
+			got, files, err := extractArchive(data, name, 10, 1<<20)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want || files != 2 {
+				t.Errorf("extractArchive = %d files\n%s\nwant 2 files\n%s", files, got, want)
+			}
+		})
+	}
+}
+
+func TestExtractArchiveLimits(t *testing.T) {
+	data := testZip(t)
+
+	got, files, err := extractArchive(data, "bundle.zip", 1, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files != 1 || strings.Contains(got, "worker.log") || !strings.HasSuffix(got, archiveTruncatedMarker) {
+		t.Errorf("entry limit: %d files\n%s", files, got)
+	}
+
+	got, files, err = extractArchive(data, "bundle.zip", 10, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files != 1 || !strings.Contains(got, "ERROR api ") || strings.Contains(got, "failed") || !strings.HasSuffix(got, archiveTruncatedMarker) {
+		t.Errorf("size limit: %d files\n%s", files, got)
+	}
+}
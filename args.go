@@ -0,0 +1,283 @@
+package main
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// AnalyzeOptions holds per-request options parsed from /analyze flags
+type AnalyzeOptions struct {
+	TicketID    string   `json:"ticket_id,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Temperature *float64 `json:"temperature,omitempty"`
+	Model       string   `json:"model,omitempty"`
+	ELI5        bool     `json:"eli5,omitempty"`
+	Question    string   `json:"question,omitempty"`
+	Instruction string   `json:"instruction,omitempty"`
+	DryRun      bool     `json:"dry_run,omitempty"`
+	TimeoutSec  int      `json:"timeout_sec,omitempty"`
+	Profile     string   `json:"profile,omitempty"`
+	NoCodebase  bool     `json:"no_codebase,omitempty"`
+	ID          string   `json:"-"` // explicit task ID from --id
+}
+
+// parseAnalyzeArgs extracts leading --flags from the analyze args
+// Flags must come before the log content; "--" ends flag parsing explicitly
+// Values containing spaces can be quoted, e.g. --ask "why is latency spiking?"
+func (p *LogAnalyzerPlugin) parseAnalyzeArgs(args []string) (AnalyzeOptions, []string, error) {
+	var opts AnalyzeOptions
+	var preset *AnalyzeOptions
+
+	i := 0
+	for ; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			i++
+			break
+		}
+		if !strings.HasPrefix(arg, "--") {
+			break
+		}
+
+		// Support both "--flag value" and "--flag=value"
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		nextValue := func() (string, error) {
+			v := value
+			if !hasValue {
+				if i+1 >= len(args) {
+					return "", p.errf("err.flag_value", name)
+				}
+				i++
+				v = args[i]
+			}
+			if !strings.HasPrefix(v, "\"") {
+				return v, nil
+			}
+			// Rejoin a quoted value split on whitespace
+			for len(v) < 2 || !strings.HasSuffix(v, "\"") {
+				if i+1 >= len(args) {
+					return "", p.errf("err.flag_unterminated", name)
+				}
+				i++
+				v += " " + args[i]
+			}
+			return v[1 : len(v)-1], nil
+		}
+
+		switch name {
+		case "ticket":
+			v, err := nextValue()
+			if err != nil {
+				return opts, nil, err
+			}
+			if strings.ContainsAny(v, " \t\r\n") || v == "" {
+				return opts, nil, p.errf("err.invalid_ticket", v)
+			}
+			opts.TicketID = v
+		case "tag":
+			v, err := nextValue()
+			if err != nil {
+				return opts, nil, err
+			}
+			if v == "" {
+				return opts, nil, p.errf("err.empty_tag")
+			}
+			if p.cfg().MaxTagsPerTask > 0 && len(opts.Tags) >= p.cfg().MaxTagsPerTask {
+				return opts, nil, p.errf("err.too_many_tags", p.cfg().MaxTagsPerTask)
+			}
+			if runes := []rune(v); p.cfg().MaxTagLength > 0 && len(runes) > p.cfg().MaxTagLength {
+				v = string(runes[:p.cfg().MaxTagLength])
+			}
+			opts.Tags = append(opts.Tags, v)
+		case "temp":
+			v, err := nextValue()
+			if err != nil {
+				return opts, nil, err
+			}
+			t, err := strconv.ParseFloat(v, 64)
+			if err != nil || math.IsNaN(t) || math.IsInf(t, 0) {
+				return opts, nil, p.errf("err.invalid_temperature", v)
+			}
+			t = p.cfg().clampTemperature(t)
+			opts.Temperature = &t
+		case "model":
+			v, err := nextValue()
+			if err != nil {
+				return opts, nil, err
+			}
+			if strings.ContainsAny(v, " \t\r\n") || v == "" {
+				return opts, nil, p.errf("err.invalid_model", v)
+			}
+			opts.Model = v
+		case "eli5":
+			opts.ELI5 = true
+		case "dry-run":
+			opts.DryRun = true
+		case "no-codebase":
+			opts.NoCodebase = true
+		case "timeout":
+			v, err := nextValue()
+			if err != nil {
+				return opts, nil, err
+			}
+			sec, err := strconv.Atoi(v)
+			if err != nil || sec <= 0 {
+				return opts, nil, p.errf("err.invalid_timeout", v)
+			}
+			if p.cfg().MaxTimeout > 0 && sec > p.cfg().MaxTimeout {
+				return opts, nil, p.errf("err.timeout_too_long", sec, p.cfg().MaxTimeout)
+			}
+			opts.TimeoutSec = sec
+		case "profile":
+			v, err := nextValue()
+			if err != nil {
+				return opts, nil, err
+			}
+			opts.Profile = v
+		case "id":
+			v, err := nextValue()
+			if err != nil {
+				return opts, nil, err
+			}
+			id, err := normalizeTaskID(v)
+			if err != nil {
+				return opts, nil, err
+			}
+			opts.ID = id
+		case "preset":
+			v, err := nextValue()
+			if err != nil {
+				return opts, nil, err
+			}
+			bundle, ok := p.cfg().Presets[v]
+			if !ok {
+				return opts, nil, p.unknownNameError("preset", v, sortedKeys(p.cfg().Presets))
+			}
+			if bundle.Temperature != nil {
+				t := p.cfg().clampTemperature(*bundle.Temperature)
+				bundle.Temperature = &t
+			}
+			preset = &bundle
+		case "ask":
+			v, err := nextValue()
+			if err != nil {
+				return opts, nil, err
+			}
+			if strings.TrimSpace(v) == "" {
+				return opts, nil, p.errf("err.empty_question")
+			}
+			opts.Question = strings.TrimSpace(v)
+		case "instruction":
+			v, err := nextValue()
+			if err != nil {
+				return opts, nil, err
+			}
+			v = strings.TrimSpace(v)
+			if v == "" {
+				return opts, nil, p.errf("err.empty_instruction")
+			}
+			if n := utf8.RuneCountInString(v); p.cfg().MaxInstructionChars > 0 && n > p.cfg().MaxInstructionChars {
+				return opts, nil, p.errf("err.instruction_too_long", n, p.cfg().MaxInstructionChars)
+			}
+			opts.Instruction = v
+		default:
+			return opts, nil, p.errf("err.unknown_flag", name)
+		}
+	}
+
+	if preset != nil {
+		opts = mergeOptions(*preset, opts)
+	}
+	if opts.Profile != "" {
+		if _, ok := p.cfg().PromptProfiles[opts.Profile]; !ok {
+			return opts, nil, p.unknownNameError("prompt profile", opts.Profile, sortedKeys(p.cfg().PromptProfiles))
+		}
+	}
+	return opts, args[i:], nil
+}
+
+// rawFieldPattern matches the whitespace-separated tokens commands are split into
+var rawFieldPattern = regexp.MustCompile(`\S+`)
+
+// rawLogContent reconstructs the log content from the raw message text, keeping the
+// original separators between args; ok is false when the text does not end with args
+// (e.g. no raw text, or the platform split it differently)
+func rawLogContent(text string, args []string) (string, bool) {
+	if len(args) == 0 {
+		return "", false
+	}
+	fields := rawFieldPattern.FindAllStringIndex(text, -1)
+	if len(fields) < len(args) {
+		return "", false
+	}
+	fields = fields[len(fields)-len(args):]
+	for i, loc := range fields {
+		if text[loc[0]:loc[1]] != args[i] {
+			return "", false
+		}
+	}
+	return text[fields[0][0]:fields[len(fields)-1][1]], true
+}
+
+// mergeOptions applies explicitly given options on top of a preset
+func mergeOptions(preset, explicit AnalyzeOptions) AnalyzeOptions {
+	merged := preset
+	merged.Tags = append([]string(nil), preset.Tags...)
+	if explicit.TicketID != "" {
+		merged.TicketID = explicit.TicketID
+	}
+	if len(explicit.Tags) > 0 {
+		merged.Tags = explicit.Tags
+	}
+	if explicit.Temperature != nil {
+		merged.Temperature = explicit.Temperature
+	}
+	if explicit.Model != "" {
+		merged.Model = explicit.Model
+	}
+	if explicit.ELI5 {
+		merged.ELI5 = true
+	}
+	if explicit.Question != "" {
+		merged.Question = explicit.Question
+	}
+	if explicit.Instruction != "" {
+		merged.Instruction = explicit.Instruction
+	}
+	if explicit.DryRun {
+		merged.DryRun = true
+	}
+	if explicit.NoCodebase {
+		merged.NoCodebase = true
+	}
+	if explicit.TimeoutSec > 0 {
+		merged.TimeoutSec = explicit.TimeoutSec
+	}
+	if explicit.Profile != "" {
+		merged.Profile = explicit.Profile
+	}
+	merged.ID = explicit.ID
+	return merged
+}
+
+// unknownNameError lists the configured names for an unknown --preset or --profile value
+func (p *LogAnalyzerPlugin) unknownNameError(kind, name string, names []string) error {
+	if len(names) == 0 {
+		return p.errf("err.unknown_name_none", kind, name)
+	}
+	return p.errf("err.unknown_name", kind, name, strings.Join(names, ", "))
+}
+
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
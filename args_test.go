@@ -0,0 +1,104 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseAnalyzeArgsTicket(t *testing.T) {
+	p, _ := newTestPlugin(DefaultConfig())
+	opts, rest, err := p.parseAnalyzeArgs([]string{"--ticket", "OPS-42", "panic:", "boom"})
+	if err != nil || opts.TicketID != "OPS-42" || strings.Join(rest, " ") != "panic: boom" {
+		t.Errorf("parseAnalyzeArgs = %+v, %q, %v", opts, rest, err)
+	}
+
+	for _, args := range [][]string{{"--ticket"}, {"--ticket="}, {"--ticket=a b"}, {"--bogus", "x"}} {
+		if _, _, err := p.parseAnalyzeArgs(args); err == nil {
+			t.Errorf("parseAnalyzeArgs(%q) succeeded, want an error", args)
+		}
+	}
+}
+
+func TestParseAnalyzeArgsTagLimits(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxTagsPerTask = 2
+	cfg.MaxTagLength = 5
+	p, _ := newTestPlugin(cfg)
+
+	opts, _, err := p.parseAnalyzeArgs([]string{"--tag", "db", "--tag=checkout-service", "log"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(opts.Tags, ",") != "db,check" {
+		t.Errorf("tags = %q, want the long tag truncated to 5 characters", opts.Tags)
+	}
+
+	if _, _, err := p.parseAnalyzeArgs([]string{"--tag", "a", "--tag", "b", "--tag", "c", "log"}); err == nil || !strings.Contains(err.Error(), "too many tags") {
+		t.Errorf("three tags with a limit of two: err = %v", err)
+	}
+	if _, _, err := p.parseAnalyzeArgs([]string{"--tag=", "log"}); err == nil {
+		t.Error("empty tag was accepted")
+	}
+}
+
+func TestParseAnalyzeArgsClampsTemperature(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MinTemperature = 0.1
+	cfg.MaxTemperature = 0.8
+	p, _ := newTestPlugin(cfg)
+
+	tests := []struct {
+		arg  string
+		want float64
+	}{
+		{"--temp=0.2", 0.2},
+		{"--temp=1.5", 0.8},
+		{"--temp=-1", 0.1},
+	}
+	for _, tt := range tests {
+		opts, _, err := p.parseAnalyzeArgs([]string{tt.arg, "log"})
+		if err != nil || opts.Temperature == nil || *opts.Temperature != tt.want {
+			t.Errorf("parseAnalyzeArgs(%s) = %v, %v, want %g", tt.arg, opts.Temperature, err, tt.want)
+		}
+	}
+
+	for _, arg := range []string{"--temp=warm", "--temp=NaN", "--temp=Inf"} {
+		if _, _, err := p.parseAnalyzeArgs([]string{arg, "log"}); err == nil {
+			t.Errorf("parseAnalyzeArgs(%s) succeeded, want an error", arg)
+		}
+	}
+}
+
+func TestParseAnalyzeArgsPreset(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxTemperature = 0.5
+	high := 0.9
+	cfg.Presets = map[string]AnalyzeOptions{
+		"oncall": {Tags: []string{"oncall"}, Temperature: &high, Model: "big-model", Question: "What broke?"},
+	}
+	p, _ := newTestPlugin(cfg)
+
+	opts, rest, err := p.parseAnalyzeArgs([]string{"--preset", "oncall", "log"})
+	if err != nil || strings.Join(rest, " ") != "log" {
+		t.Fatalf("parseAnalyzeArgs = %+v, %q, %v", opts, rest, err)
+	}
+	if strings.Join(opts.Tags, ",") != "oncall" || opts.Question != "What broke?" || opts.Model != "big-model" || opts.Temperature == nil || *opts.Temperature != 0.5 {
+		t.Errorf("preset options = %+v, want the preset applied with its temperature clamped", opts)
+	}
+
+	// Explicit flags win regardless of their position relative to --preset
+	opts, _, err = p.parseAnalyzeArgs([]string{"--tag", "db", "--preset=oncall", "--temp", "0.1", "--model", "small-model", "--eli5", "log"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(opts.Tags, ",") != "db" || *opts.Temperature != 0.1 || opts.Model != "small-model" || !opts.ELI5 || opts.Question != "What broke?" {
+		t.Errorf("merged options = %+v, want explicit tag, temperature and model over the preset", opts)
+	}
+	if strings.Join(cfg.Presets["oncall"].Tags, ",") != "oncall" || *cfg.Presets["oncall"].Temperature != 0.9 {
+		t.Errorf("preset was modified by parsing: %+v", cfg.Presets["oncall"])
+	}
+
+	if _, _, err := p.parseAnalyzeArgs([]string{"--preset", "nope", "log"}); err == nil || !strings.Contains(err.Error(), "available: oncall") {
+		t.Errorf("unknown preset: err = %v, want the available presets listed", err)
+	}
+}
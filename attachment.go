@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/DaikonSushi/bot-platform/pkg/pluginsdk"
+)
+
+// fileAttachment describes a file segment on an incoming message
+type fileAttachment struct {
+	Name   string
+	FileID string
+	URL    string
+}
+
+// findFileAttachment returns the first file segment of a message
+func findFileAttachment(msg *pluginsdk.Message) (fileAttachment, bool) {
+	for _, seg := range msg.Segments {
+		if seg.Type != "file" {
+			continue
+		}
+		att := fileAttachment{
+			Name:   seg.Data["file"],
+			FileID: seg.Data["file_id"],
+			URL:    seg.Data["url"],
+		}
+		if name := seg.Data["name"]; name != "" {
+			att.Name = name
+		}
+		return att, true
+	}
+	return fileAttachment{}, false
+}
+
+// getFileResponse is the subset of the NapCat get_file response we use
+type getFileResponse struct {
+	File string `json:"file"`
+	URL  string `json:"url"`
+}
+
+// readAttachment reads an attachment into memory, capped at maxBytes
+// Nothing is written to disk, so unredacted content never leaves memory
+func (p *LogAnalyzerPlugin) readAttachment(att fileAttachment, maxBytes int64) ([]byte, error) {
+	src, err := p.openAttachment(att)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(io.LimitReader(src, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, fmt.Errorf("file is larger than %d bytes", maxBytes)
+	}
+	return data, nil
+}
+
+// openAttachment opens an attachment from its URL, resolving it via the bot API if needed
+func (p *LogAnalyzerPlugin) openAttachment(att fileAttachment) (io.ReadCloser, error) {
+	location := att.URL
+	if location == "" && att.FileID != "" {
+		info, err := p.resolveFile(att.FileID)
+		if err != nil {
+			return nil, err
+		}
+		location = info.URL
+		if location == "" {
+			location = info.File
+		}
+	}
+	if location == "" {
+		return nil, fmt.Errorf("attachment %s has no download location", att.Name)
+	}
+
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		resp, err := p.cfg().httpClient.Get(location)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download attachment: %v", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("failed to download attachment: %s", resp.Status)
+		}
+		return resp.Body, nil
+	}
+
+	return os.Open(strings.TrimPrefix(location, "file://"))
+}
+
+// resolveFile looks up a file's location through the NapCat get_file API
+func (p *LogAnalyzerPlugin) resolveFile(fileID string) (getFileResponse, error) {
+	data, err := p.bot.CallAPI("get_file", map[string]string{"file_id": fileID})
+	if err != nil {
+		return getFileResponse{}, fmt.Errorf("failed to resolve attachment: %v", err)
+	}
+
+	// The response may or may not be wrapped in a {"data": ...} envelope
+	var envelope struct {
+		Data *getFileResponse `json:"data"`
+	}
+	if err := json.Unmarshal(data, &envelope); err == nil && envelope.Data != nil {
+		return *envelope.Data, nil
+	}
+	var info getFileResponse
+	if err := json.Unmarshal(data, &info); err != nil {
+		return getFileResponse{}, fmt.Errorf("failed to decode attachment info: %v", err)
+	}
+	return info, nil
+}
+
+// isBinary reports whether data looks like binary rather than text
+func isBinary(data []byte) bool {
+	if len(data) > 8192 {
+		data = data[:8192]
+	}
+	if len(data) == 0 {
+		return false
+	}
+
+	nonPrintable := 0
+	for _, b := range data {
+		if b == 0 {
+			return true
+		}
+		if b < 0x20 && b != '\n' && b != '\r' && b != '\t' && b != '\f' && b != 0x1b {
+			nonPrintable++
+		}
+	}
+	return nonPrintable*10 > len(data)
+}
+
+// isLogFileName reports whether an attachment is a plain-text log file
+func isLogFileName(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".txt" || ext == ".log"
+}
+
+// loadLogAttachment reads a .txt/.log attachment and returns its text
+func (p *LogAnalyzerPlugin) loadLogAttachment(att fileAttachment) (string, error) {
+	data, err := p.readAttachment(att, p.cfg().MaxAttachmentBytes)
+	if err != nil {
+		return "", err
+	}
+	if isBinary(data) {
+		return "", fmt.Errorf("file appears to be binary")
+	}
+	return string(data), nil
+}
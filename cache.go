@@ -0,0 +1,178 @@
+package main
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// cacheEntry is a cached analysis result
+// File-backed entries keep only outputPath and re-read the content on a hit
+type cacheEntry struct {
+	key        string
+	taskID     string
+	content    string
+	outputPath string
+	fileBacked bool
+	createdAt  time.Time
+}
+
+// resultCache is a size-bounded LRU cache of analysis results with a TTL
+type resultCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List // front is most recently used
+
+	hits   int64
+	misses int64
+}
+
+// newResultCache creates a cache holding up to maxEntries results for ttl
+func newResultCache(maxEntries int, ttl time.Duration) *resultCache {
+	return &resultCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// Get returns the cached entry for key if present and not expired
+func (c *resultCache) Get(key string) (cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return cacheEntry{}, false
+	}
+
+	entry := elem.Value.(*cacheEntry)
+	if time.Since(entry.createdAt) > c.ttl {
+		c.removeLocked(elem)
+		c.misses++
+		return cacheEntry{}, false
+	}
+
+	c.order.MoveToFront(elem)
+	c.hits++
+	return *entry, true
+}
+
+// Put stores an entry, evicting the least recently used one when full
+func (c *resultCache) Put(entry cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[entry.key]; ok {
+		c.removeLocked(elem)
+	}
+
+	c.entries[entry.key] = c.order.PushFront(&entry)
+	for c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		c.removeLocked(c.order.Back())
+	}
+}
+
+// Remove drops the entry for key if present
+func (c *resultCache) Remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.removeLocked(elem)
+	}
+}
+
+// RemoveByPath drops file-backed entries whose output file was cleaned up
+func (c *resultCache) RemoveByPath(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, elem := range c.entries {
+		if entry := elem.Value.(*cacheEntry); entry.fileBacked && entry.outputPath == path {
+			c.removeLocked(elem)
+		}
+	}
+}
+
+// Stats returns the hit and miss counts and the number of cached entries
+func (c *resultCache) Stats() (hits, misses int64, entries int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses, c.order.Len()
+}
+
+// removeLocked drops an element; the caller must hold c.mu
+func (c *resultCache) removeLocked(elem *list.Element) {
+	entry := elem.Value.(*cacheEntry)
+	delete(c.entries, entry.key)
+	c.order.Remove(elem)
+}
+
+// cacheKeyFor returns the cache key for a task's final prompt
+// Anything besides the prompt that changes the result must be part of the key
+func (p *LogAnalyzerPlugin) cacheKeyFor(task *TaskStatus, prompt string) string {
+	h := sha256.New()
+	h.Write([]byte(task.config.Mode))
+	h.Write([]byte{0})
+	h.Write([]byte(prompt))
+	if temp := p.temperatureFor(task); temp != nil {
+		fmt.Fprintf(h, "\x00temperature=%g", *temp)
+	}
+	fmt.Fprintf(h, "\x00model=%s\x00format=%s", p.modelFor(task), task.config.OutputFormat)
+	if task.config.Mode == "direct" && !p.useCodebase(task) {
+		h.Write([]byte("\x00no-codebase"))
+	}
+	if task.Options.Profile != "" {
+		fmt.Fprintf(h, "\x00profile=%s", task.Options.Profile)
+	}
+	if task.Options.Instruction != "" {
+		fmt.Fprintf(h, "\x00instruction=%s", task.Options.Instruction)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cacheResult stores a completed task's result for later identical submissions
+// Results over MaxCachedResultBytes are cached by output file path instead of in memory
+// The result goes into the live cache, which a reload may have replaced since the task started
+func (p *LogAnalyzerPlugin) cacheResult(task *TaskStatus, outputPath, content string) {
+	cache := p.cfg().cache
+	if cache == nil || task.cacheKey == "" || task.Cached {
+		return
+	}
+	entry := cacheEntry{
+		key:        task.cacheKey,
+		taskID:     task.ID,
+		content:    content,
+		outputPath: outputPath,
+		createdAt:  time.Now(),
+	}
+	if task.config.MaxCachedResultBytes > 0 && len(content) > task.config.MaxCachedResultBytes {
+		if outputPath == "" {
+			return
+		}
+		entry.content = ""
+		entry.fileBacked = true
+	}
+	cache.Put(entry)
+}
+
+// cachedContent returns an entry's result, reading file-backed entries from disk
+// Entries whose file is gone are evicted and reported as a miss
+func (p *LogAnalyzerPlugin) cachedContent(cache *resultCache, entry cacheEntry) (string, bool) {
+	if !entry.fileBacked {
+		return entry.content, true
+	}
+	data, err := os.ReadFile(entry.outputPath)
+	if err != nil {
+		cache.Remove(entry.key)
+		return "", false
+	}
+	return string(data), true
+}
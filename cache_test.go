@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCacheResultLargeResultIsFileBacked(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxCachedResultBytes = 16
+	cfg.CacheTTLMinutes = 60
+	p, _ := newTestPlugin(cfg)
+	cache := p.cfg().cache
+
+	small := &TaskStatus{config: p.cfg(), ID: "SMALL", cacheKey: "k-small"}
+	p.cacheResult(small, "", "short result")
+	entry, ok := cache.Get("k-small")
+	if !ok || entry.fileBacked || entry.content != "short result" {
+		t.Fatalf("small entry = %+v, %v; want in-memory content", entry, ok)
+	}
+
+	outputPath := filepath.Join(t.TempDir(), "result.md")
+	large := strings.Repeat("x", 64)
+	if err := os.WriteFile(outputPath, []byte(large), 0644); err != nil {
+		t.Fatal(err)
+	}
+	task := &TaskStatus{config: p.cfg(), ID: "LARGE", cacheKey: "k-large"}
+	p.cacheResult(task, outputPath, large)
+
+	entry, ok = cache.Get("k-large")
+	if !ok || !entry.fileBacked || entry.content != "" || entry.outputPath != outputPath {
+		t.Fatalf("large entry = %+v, %v; want file-backed with no content", entry, ok)
+	}
+	content, ok := p.cachedContent(cache, entry)
+	if !ok || content != large {
+		t.Errorf("cachedContent = %q, %v; want the file contents", content, ok)
+	}
+
+	// Without an output file there is nothing to re-read, so the result is not cached
+	p.cacheResult(&TaskStatus{config: p.cfg(), ID: "NOFILE", cacheKey: "k-nofile"}, "", large)
+	if _, ok := cache.Get("k-nofile"); ok {
+		t.Error("large result without an output file was cached")
+	}
+}
+
+func TestCachedContentEvictsMissingFile(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxCachedResultBytes = 1
+	cfg.CacheTTLMinutes = 60
+	p, _ := newTestPlugin(cfg)
+	cache := p.cfg().cache
+
+	outputPath := filepath.Join(t.TempDir(), "result.md")
+	if err := os.WriteFile(outputPath, []byte("result"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p.cacheResult(&TaskStatus{config: p.cfg(), ID: "T1", cacheKey: "k"}, outputPath, "result")
+	entry, _ := cache.Get("k")
+	if err := os.Remove(outputPath); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := p.cachedContent(cache, entry); ok {
+		t.Fatal("cachedContent succeeded for a removed file")
+	}
+	if _, ok := cache.Get("k"); ok {
+		t.Error("entry with a removed file was not evicted")
+	}
+}
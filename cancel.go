@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/DaikonSushi/bot-platform/pkg/pluginsdk"
+)
+
+// errTaskCancelled finishes tasks stopped with /analyzecancel
+var errTaskCancelled = errors.New("cancelled by user")
+
+// isFinished reports whether a task status is terminal
+func isFinished(status string) bool {
+	return status == "completed" || status == "failed" || status == "cancelled"
+}
+
+// handleCancel handles the analyzecancel command
+// Only the task owner or an admin can cancel; pending tasks never start, running ones are stopped
+func (p *LogAnalyzerPlugin) handleCancel(bot *pluginsdk.BotClient, args []string, msg *pluginsdk.Message) {
+	if len(args) != 1 {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("usage.cancel")))
+		return
+	}
+
+	taskID := strings.ToUpper(args[0])
+	p.taskMutex.RLock()
+	task, exists := p.tasks[taskID]
+	var owner int64
+	var status, proxyURL string
+	var cancel func()
+	if exists {
+		owner, status, cancel, proxyURL = task.UserID, task.Status, task.cancel, task.proxyURL
+	}
+	p.taskMutex.RUnlock()
+
+	if !exists {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.task_not_found", taskID)))
+		return
+	}
+	if owner != msg.UserID && !p.isAdmin(msg.UserID) {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.cancel_not_owner")))
+		return
+	}
+	if isFinished(status) {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.cancel_finished", taskID, p.statusLabel(status))))
+		return
+	}
+
+	if !p.finishTask(task, errTaskCancelled) {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.cancel_too_late", taskID)))
+		return
+	}
+
+	// Stop the work in flight; the runner releases its slots once it returns
+	if cancel != nil {
+		cancel()
+	}
+	if status == "running" && proxyURL != "" {
+		go p.cancelProxyTask(proxyURL, taskID)
+	}
+
+	p.taskLogf("info", taskID, "Cancelled by user %d", msg.UserID)
+	bot.Reply(msg, pluginsdk.Text(p.msgf("cancel.done", taskID)))
+}
+
+// cancelProxyTask asks the proxy instance running an analysis to stop it
+func (p *LogAnalyzerPlugin) cancelProxyTask(proxyURL, taskID string) {
+	req, err := p.newProxyRequest(context.Background(), http.MethodDelete, proxyEndpoint(proxyURL, "cancel", taskID), nil)
+	if err != nil {
+		p.taskLogf("warn", taskID, "Failed to build cancel request: %v", err)
+		return
+	}
+	resp, err := p.doProxyRequest(req)
+	if err != nil {
+		p.taskLogf("warn", taskID, "Failed to cancel on proxy: %v", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		p.taskLogf("warn", taskID, "Proxy cancel returned %s", resp.Status)
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/DaikonSushi/bot-platform/pkg/pluginsdk"
+)
+
+// loadConfigFile overlays settings from a JSON config file onto cfg
+// Keys use the Config json tags; settings absent from the file keep their current values
+func loadConfigFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// maskedConfigFields are the Config json keys whose values /analyzeconfig never shows
+// Webhook URLs are included because they commonly embed access tokens
+var maskedConfigFields = map[string]bool{
+	"proxy_api_key":  true,
+	"api_token":      true,
+	"ticket_webhook": true,
+	"webhook_url":    true,
+}
+
+// restartOnlyConfigFields are read once at startup; /analyzereload reports changes to
+// them but they only take effect after a restart
+var restartOnlyConfigFields = map[string]bool{
+	"shared_data_path":         true,
+	"strict_shared_data_path":  true,
+	"task_id_prefix":           true,
+	"metrics_addr":             true,
+	"cleanup_interval_minutes": true,
+	"watchdog_interval_sec":    true,
+}
+
+// configSetting is one Config field, keyed by its settings file name
+type configSetting struct {
+	name  string
+	index int // field index in Config
+	value reflect.Value
+}
+
+// configSettings lists the settings of cfg in declaration order
+func configSettings(cfg Config) []configSetting {
+	v := reflect.ValueOf(cfg)
+	t := v.Type()
+	var settings []configSetting
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if !t.Field(i).IsExported() || name == "" || name == "-" {
+			continue
+		}
+		settings = append(settings, configSetting{name: name, index: i, value: v.Field(i)})
+	}
+	return settings
+}
+
+// String renders the setting's value as JSON, masking secret fields
+func (s configSetting) String() string {
+	if maskedConfigFields[s.name] {
+		if s.value.IsZero() {
+			return `""`
+		}
+		return "***"
+	}
+	data, err := json.Marshal(s.value.Interface())
+	if err != nil {
+		return fmt.Sprintf("%v", s.value.Interface())
+	}
+	return string(data)
+}
+
+// formatConfig renders the effective configuration one setting per line, keyed by
+// the settings file names, with secret fields masked
+func formatConfig(cfg Config) string {
+	var sb strings.Builder
+	for _, s := range configSettings(cfg) {
+		sb.WriteString(fmt.Sprintf("%s: %s\n", s.name, s))
+	}
+	return sb.String()
+}
+
+// keepRestartOnlySettings copies the restart-only settings of running into cfg, so the
+// live configuration keeps matching what was set up at startup
+func keepRestartOnlySettings(cfg *Config, running Config) {
+	dst := reflect.ValueOf(cfg).Elem()
+	for _, s := range configSettings(running) {
+		if restartOnlyConfigFields[s.name] {
+			dst.Field(s.index).Set(s.value)
+		}
+	}
+}
+
+// diffConfig describes the settings that differ between two configurations
+func diffConfig(before, after Config) []string {
+	old := configSettings(before)
+	var changes []string
+	for i, s := range configSettings(after) {
+		if reflect.DeepEqual(old[i].value.Interface(), s.value.Interface()) {
+			continue
+		}
+		line := fmt.Sprintf("%s: %s → %s", s.name, old[i], s)
+		if maskedConfigFields[s.name] {
+			line = fmt.Sprintf("%s: changed", s.name)
+		}
+		if restartOnlyConfigFields[s.name] {
+			line += " (restart required)"
+		}
+		changes = append(changes, line)
+	}
+	return changes
+}
+
+// handleConfig handles the analyzeconfig admin command
+func (p *LogAnalyzerPlugin) handleConfig(bot *pluginsdk.BotClient, msg *pluginsdk.Message) {
+	if !p.isAdmin(msg.UserID) {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.admin_only")))
+		return
+	}
+	p.replyLong(bot, msg, p.msgf("config.title")+"\n━━━━━━━━━━━━━━━━━━━━\n"+strings.TrimSuffix(formatConfig(*p.cfg()), "\n"))
+}
+
+// handleReload handles the analyzereload admin command
+// The config file and environment are read again into a new snapshot, fully built before
+// it replaces the current one; tasks already created keep the snapshot they started with
+func (p *LogAnalyzerPlugin) handleReload(bot *pluginsdk.BotClient, msg *pluginsdk.Message) {
+	if !p.isAdmin(msg.UserID) {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.admin_only")))
+		return
+	}
+
+	p.reloadMutex.Lock()
+	cfg := p.loadConfig()
+	previous := p.cfg()
+	changes := diffConfig(*previous, cfg)
+	keepRestartOnlySettings(&cfg, *previous)
+	p.buildDerivedState(&cfg, previous)
+	p.config.Store(&cfg)
+	if previous.MaxConcurrentPerGroup != cfg.MaxConcurrentPerGroup {
+		p.resetGroupSlots()
+	}
+	p.reloadMutex.Unlock()
+
+	p.logf("info", "Configuration reloaded by user %d, %d setting(s) changed", msg.UserID, len(changes))
+	if len(changes) == 0 {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("config.reloaded_none")))
+		return
+	}
+	p.replyLong(bot, msg, p.msgf("config.reloaded")+"\n━━━━━━━━━━━━━━━━━━━━\n"+strings.Join(changes, "\n"))
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	content := `{"timeout": 42, "presets": {"newbie": {"eli5": true, "question": "Where do I start?"}}}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultConfig()
+	if err := loadConfigFile(path, &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Timeout != 42 {
+		t.Errorf("Timeout = %d, want 42 from the file", cfg.Timeout)
+	}
+	if cfg.MaxReplyChars != DefaultConfig().MaxReplyChars {
+		t.Errorf("MaxReplyChars = %d, want the default kept", cfg.MaxReplyChars)
+	}
+	if preset := cfg.Presets["newbie"]; !preset.ELI5 || preset.Question != "Where do I start?" {
+		t.Errorf("preset = %+v, want it loaded from the file", preset)
+	}
+
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadConfigFile(path, &cfg); err == nil {
+		t.Error("loadConfigFile accepted invalid JSON")
+	}
+	if err := loadConfigFile(filepath.Join(t.TempDir(), "missing.json"), &cfg); err == nil {
+		t.Error("loadConfigFile accepted a missing file")
+	}
+}
@@ -0,0 +1,481 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DaikonSushi/bot-platform/pkg/pluginsdk"
+)
+
+// cronJobsFile is the file (under SharedDataPath) persisting scheduled analyses
+const cronJobsFile = "cron_jobs.json"
+
+// cronMaxLogBytes caps how much of a log source is analyzed per run (the tail is kept)
+const cronMaxLogBytes = 64 * 1024
+
+// CronJob is a recurring analysis of a log source
+type CronJob struct {
+	ID        string    `json:"id"`
+	Spec      string    `json:"spec"`
+	Source    string    `json:"source"`
+	UserID    int64     `json:"user_id"`
+	GroupID   int64     `json:"group_id"`
+	CreatedAt time.Time `json:"created_at"`
+	LastRun   time.Time `json:"last_run,omitempty"`
+
+	schedule *cronSchedule
+}
+
+// cronScheduler runs registered cron jobs
+type cronScheduler struct {
+	mu   sync.Mutex
+	jobs map[string]*CronJob
+	path string
+}
+
+// cronSchedule is a parsed 5-field cron expression (minute hour day-of-month month day-of-week)
+type cronSchedule struct {
+	minute, hour, dom, month, dow []bool
+	domStar, dowStar              bool
+}
+
+// parseCronSpec parses a standard 5-field cron expression
+// Each field supports "*", numbers, ranges "a-b", lists "a,b" and steps "*/n" or "a-b/n"
+func parseCronSpec(spec string) (*cronSchedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression must have 5 fields, got %d", len(fields))
+	}
+
+	var (
+		s   cronSchedule
+		err error
+	)
+	if s.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("minute: %v", err)
+	}
+	if s.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("hour: %v", err)
+	}
+	if s.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("day of month: %v", err)
+	}
+	if s.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("month: %v", err)
+	}
+	if s.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("day of week: %v", err)
+	}
+	// Both 0 and 7 mean Sunday
+	if s.dow[7] {
+		s.dow[0] = true
+	}
+	s.domStar = fields[2] == "*"
+	s.dowStar = fields[4] == "*"
+
+	return &s, nil
+}
+
+// parseCronField parses one cron field into a lookup table indexed by value
+func parseCronField(field string, min, max int) ([]bool, error) {
+	set := make([]bool, max+1)
+
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid step %q", stepPart)
+			}
+			step = n
+		}
+
+		lo, hi := min, max
+		if rangePart != "*" {
+			loStr, hiStr, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = strconv.Atoi(loStr); err != nil {
+				return nil, fmt.Errorf("invalid value %q", loStr)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(hiStr); err != nil {
+					return nil, fmt.Errorf("invalid value %q", hiStr)
+				}
+			} else if hasStep {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return nil, fmt.Errorf("value out of range %d-%d in %q", min, max, part)
+		}
+
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+
+	return set, nil
+}
+
+// matches reports whether the schedule fires at the minute containing t
+func (s *cronSchedule) matches(t time.Time) bool {
+	if !s.minute[t.Minute()] || !s.hour[t.Hour()] || !s.month[int(t.Month())] {
+		return false
+	}
+	// Standard cron semantics: when both day fields are restricted, either may match
+	domMatch := s.dom[t.Day()]
+	dowMatch := s.dow[int(t.Weekday())]
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// newCronScheduler creates a scheduler persisting jobs to path
+func newCronScheduler(path string) *cronScheduler {
+	return &cronScheduler{
+		jobs: make(map[string]*CronJob),
+		path: path,
+	}
+}
+
+// load reads persisted jobs, skipping any that no longer parse
+func (c *cronScheduler) load() error {
+	data, err := os.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var jobs []*CronJob
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, job := range jobs {
+		schedule, err := parseCronSpec(job.Spec)
+		if err != nil {
+			continue
+		}
+		job.schedule = schedule
+		c.jobs[job.ID] = job
+	}
+	return nil
+}
+
+// saveLocked writes all jobs to disk; the caller must hold c.mu
+func (c *cronScheduler) saveLocked() error {
+	jobs := make([]*CronJob, 0, len(c.jobs))
+	for _, job := range c.jobs {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].CreatedAt.Before(jobs[j].CreatedAt) })
+
+	data, err := json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, data, 0644)
+}
+
+// add registers and persists a job
+func (c *cronScheduler) add(job *CronJob) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.jobs[job.ID] = job
+	return c.saveLocked()
+}
+
+// remove deletes a job owned by userID
+func (c *cronScheduler) remove(id string, userID int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	job, ok := c.jobs[id]
+	if !ok || job.UserID != userID {
+		return fmt.Errorf("cron job not found: %s", id)
+	}
+	delete(c.jobs, id)
+	return c.saveLocked()
+}
+
+// list returns the jobs owned by userID, oldest first
+func (c *cronScheduler) list(userID int64) []CronJob {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var jobs []CronJob
+	for _, job := range c.jobs {
+		if job.UserID == userID {
+			jobs = append(jobs, *job)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].CreatedAt.Before(jobs[j].CreatedAt) })
+	return jobs
+}
+
+// due returns the jobs that should run at the minute containing now and marks them as run
+func (c *cronScheduler) due(now time.Time) []*CronJob {
+	minute := now.Truncate(time.Minute)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var due []*CronJob
+	for _, job := range c.jobs {
+		if job.schedule.matches(minute) && job.LastRun.Before(minute) {
+			job.LastRun = minute
+			due = append(due, job)
+		}
+	}
+	if len(due) > 0 {
+		c.saveLocked()
+	}
+	return due
+}
+
+// newTimeTicker is the real ticker factory behind LogAnalyzerPlugin.newTicker
+func newTimeTicker(d time.Duration) (<-chan time.Time, func()) {
+	ticker := time.NewTicker(d)
+	return ticker.C, ticker.Stop
+}
+
+// runCronScheduler checks registered jobs every minute until the plugin stops
+// Jobs are matched against p.now() rather than the tick time so a fake clock drives them
+func (p *LogAnalyzerPlugin) runCronScheduler() {
+	ticks, stop := p.newTicker(time.Minute)
+	defer stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticks:
+			p.runDueCronJobs(p.now())
+		}
+	}
+}
+
+// runDueCronJobs starts an analysis for every job scheduled at now
+func (p *LogAnalyzerPlugin) runDueCronJobs(now time.Time) {
+	for _, job := range p.cron.due(now) {
+		p.runCronJob(job)
+	}
+}
+
+// runCronJob analyzes the job's log source and delivers the result to its owner
+func (p *LogAnalyzerPlugin) runCronJob(job *CronJob) {
+	msg := &pluginsdk.Message{
+		UserID:  job.UserID,
+		GroupID: job.GroupID,
+		Type:    "private",
+	}
+	if job.GroupID > 0 {
+		msg.Type = "group"
+	}
+
+	logContent, err := p.readCronSource(job.Source)
+	if err != nil {
+		p.logf("warn", "[cron %s] Failed to read log source: %v", job.ID, err)
+		p.bot.Reply(msg, pluginsdk.Text(p.msgf("err.cron_failed", job.ID, err)))
+		return
+	}
+
+	// Scheduled logs get the same redaction, size limit and format detection as uploads
+	var opts AnalyzeOptions
+	prepared, err := p.prepareLog(&opts, logContent)
+	if errors.Is(err, errEmptyLog) {
+		p.logf("info", "[cron %s] Log source is empty, skipping", job.ID)
+		return
+	}
+	if err != nil {
+		p.logf("warn", "[cron %s] Log source rejected: %v", job.ID, err)
+		p.bot.Reply(msg, pluginsdk.Text(p.msgf("err.cron_failed", job.ID, err)))
+		return
+	}
+
+	task := p.createTask(msg, opts)
+	task.InputTruncated = prepared.truncated
+	task.LogFormat = prepared.format
+	task.logContent = prepared.content
+	p.taskLogf("info", task.ID, "Started scheduled analysis for cron job %s", job.ID)
+	p.startAnalysis(task, prepared.content, msg)
+}
+
+// resolveCronSource resolves a log source path inside CronLogDir
+func (p *LogAnalyzerPlugin) resolveCronSource(source string) (string, error) {
+	if p.cfg().CronLogDir == "" {
+		return "", fmt.Errorf("scheduled analysis is not configured (LOGANALYZER_CRON_LOG_DIR not set)")
+	}
+
+	base, err := filepath.Abs(p.cfg().CronLogDir)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(base, source)
+	if filepath.IsAbs(source) {
+		path = filepath.Clean(source)
+	}
+	if rel, err := filepath.Rel(base, path); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("log source must be inside %s", base)
+	}
+	return path, nil
+}
+
+// readCronSource reads the tail of a log source, bounded by cronMaxLogBytes
+func (p *LogAnalyzerPlugin) readCronSource(source string) (string, error) {
+	path, err := p.resolveCronSource(source)
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	if info.Size() > cronMaxLogBytes {
+		if _, err := f.Seek(-cronMaxLogBytes, io.SeekEnd); err != nil {
+			return "", err
+		}
+	}
+
+	data, err := io.ReadAll(io.LimitReader(f, cronMaxLogBytes))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// handleCron handles the analyzecron command
+func (p *LogAnalyzerPlugin) handleCron(bot *pluginsdk.BotClient, args []string, msg *pluginsdk.Message) {
+	if len(args) == 0 {
+		p.replyCronUsage(bot, msg)
+		return
+	}
+
+	switch args[0] {
+	case "add":
+		p.handleCronAdd(bot, args[1:], msg)
+	case "list":
+		p.handleCronList(bot, msg)
+	case "remove":
+		if len(args) < 2 {
+			bot.Reply(msg, pluginsdk.Text(p.msgf("usage.cron_remove")))
+			return
+		}
+		if err := p.cron.remove(strings.ToUpper(args[1]), msg.UserID); err != nil {
+			bot.Reply(msg, pluginsdk.Text(p.msgf("err.generic", err)))
+			return
+		}
+		bot.Reply(msg, pluginsdk.Text(p.msgf("cron.removed", strings.ToUpper(args[1]))))
+	default:
+		p.replyCronUsage(bot, msg)
+	}
+}
+
+// handleCronAdd registers a new scheduled analysis
+func (p *LogAnalyzerPlugin) handleCronAdd(bot *pluginsdk.BotClient, args []string, msg *pluginsdk.Message) {
+	spec, rest, err := splitCronSpec(args)
+	if err != nil {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.generic", err)))
+		return
+	}
+
+	schedule, err := parseCronSpec(spec)
+	if err != nil {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.cron_invalid", err)))
+		return
+	}
+
+	if len(rest) != 2 || rest[0] != "--file" {
+		p.replyCronUsage(bot, msg)
+		return
+	}
+	source := rest[1]
+	if _, err := p.resolveCronSource(source); err != nil {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.generic", err)))
+		return
+	}
+
+	job := &CronJob{
+		ID:        generateShortID(),
+		Spec:      spec,
+		Source:    source,
+		UserID:    msg.UserID,
+		GroupID:   msg.GroupID,
+		CreatedAt: p.now(),
+		LastRun:   p.now().Truncate(time.Minute),
+		schedule:  schedule,
+	}
+	if err := p.cron.add(job); err != nil {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.cron_save", err)))
+		return
+	}
+
+	bot.Reply(msg,
+		pluginsdk.Text(p.msgf("cron.registered")+"\n"),
+		pluginsdk.Text("━━━━━━━━━━━━━━━━━━━━\n"),
+		pluginsdk.Text(p.msgf("cron.job_id", job.ID)+"\n"),
+		pluginsdk.Text(p.msgf("cron.schedule", job.Spec)+"\n"),
+		pluginsdk.Text(p.msgf("cron.source", job.Source)),
+	)
+}
+
+// handleCronList lists the requester's scheduled analyses
+func (p *LogAnalyzerPlugin) handleCronList(bot *pluginsdk.BotClient, msg *pluginsdk.Message) {
+	jobs := p.cron.list(msg.UserID)
+	if len(jobs) == 0 {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("cron.none")))
+		return
+	}
+
+	response := p.msgf("cron.list_title") + "\n━━━━━━━━━━━━━━━━━━━━\n"
+	for _, job := range jobs {
+		response += fmt.Sprintf("%s: [%s] %s\n", job.ID, job.Spec, job.Source)
+	}
+	bot.Reply(msg, pluginsdk.Text(response))
+}
+
+// replyCronUsage shows analyzecron usage
+func (p *LogAnalyzerPlugin) replyCronUsage(bot *pluginsdk.BotClient, msg *pluginsdk.Message) {
+	bot.Reply(msg, pluginsdk.Text(p.msgf("usage.cron")))
+}
+
+// splitCronSpec extracts the cron expression from args, quoted or as the first 5 fields
+func splitCronSpec(args []string) (string, []string, error) {
+	if len(args) > 0 && strings.HasPrefix(args[0], "\"") {
+		for i, arg := range args {
+			if strings.HasSuffix(arg, "\"") && (i > 0 || len(arg) > 1) {
+				spec := strings.Trim(strings.Join(args[:i+1], " "), "\"")
+				return spec, args[i+1:], nil
+			}
+		}
+		return "", nil, fmt.Errorf("unterminated quoted cron expression")
+	}
+
+	if len(args) < 5 {
+		return "", nil, fmt.Errorf("missing cron expression")
+	}
+	return strings.Join(args[:5], " "), args[5:], nil
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DaikonSushi/bot-platform/pkg/pluginsdk"
+)
+
+func TestCronScheduleMatches(t *testing.T) {
+	// 2026-03-02 is a Monday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, time.March, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		spec string
+		t    time.Time
+		want bool
+	}{
+		{"* * * * *", at(2, 10, 7), true},
+		{"*/15 * * * *", at(2, 10, 30), true},
+		{"*/15 * * * *", at(2, 10, 31), false},
+		{"0 9-17 * * *", at(2, 17, 0), true},
+		{"0 9-17 * * *", at(2, 18, 0), false},
+		{"30 2 * * 1", at(2, 2, 30), true},
+		{"30 2 * * 1", at(3, 2, 30), false},
+		{"0 0 * * 7", at(1, 0, 0), true},                         // 7 is Sunday as well as 0
+		{"0 0 15 * 1", at(2, 0, 0), true},                        // restricted day fields: Monday matches
+		{"0 0 15 * 1", at(15, 0, 0), true},                       // restricted day fields: the 15th matches
+		{"0 0 15 * 1", at(3, 0, 0), false},                       // neither day field matches
+		{"0 0 1,15 3 *", at(15, 0, 0), true},                     // list
+		{"5-20/5 * * * *", at(2, 4, 20), true},                   // stepped range
+		{"5-20/5 * * * *", at(2, 4, 25), false},                  // past the range
+		{"0 12 * * *", at(2, 12, 0).Add(59 * time.Second), true}, // any second of the minute
+	}
+
+	for _, tt := range tests {
+		s, err := parseCronSpec(tt.spec)
+		if err != nil {
+			t.Fatalf("parseCronSpec(%q): %v", tt.spec, err)
+		}
+		if got := s.matches(tt.t); got != tt.want {
+			t.Errorf("%q matches %s = %v, want %v", tt.spec, tt.t.Format(time.RFC3339), got, tt.want)
+		}
+	}
+}
+
+func TestParseCronSpecErrors(t *testing.T) {
+	for _, spec := range []string{
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+	} {
+		if _, err := parseCronSpec(spec); err == nil {
+			t.Errorf("parseCronSpec(%q) succeeded, want error", spec)
+		}
+	}
+}
+
+func TestCronSchedulerDueWithFakeClock(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)}
+	schedule, err := parseCronSpec("*/15 9 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := newCronScheduler(filepath.Join(t.TempDir(), cronJobsFile))
+	job := &CronJob{ID: "J1", Spec: "*/15 9 * * *", CreatedAt: clock.Now(), LastRun: clock.Now().Add(-time.Minute), schedule: schedule}
+	if err := c.add(job); err != nil {
+		t.Fatal(err)
+	}
+
+	// Tick twice a minute for two hours: the job runs at :00, :15, :30 and :45 of 9:00 only,
+	// once per matching minute however often the scheduler checks
+	var runs []time.Time
+	for i := 0; i < 2*60*2; i++ {
+		for _, due := range c.due(clock.Now()) {
+			runs = append(runs, due.LastRun)
+		}
+		clock.Advance(30 * time.Second)
+	}
+
+	want := []string{"09:00", "09:15", "09:30", "09:45"}
+	if len(runs) != len(want) {
+		t.Fatalf("got %d runs %v, want %v", len(runs), runs, want)
+	}
+	for i, run := range runs {
+		if got := run.Format("15:04"); got != want[i] {
+			t.Errorf("run %d at %s, want %s", i, got, want[i])
+		}
+	}
+}
+
+func TestRunCronSchedulerStopsOnDone(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)}
+	ticks := make(chan time.Time)
+	stopped := make(chan struct{})
+	p := &LogAnalyzerPlugin{
+		done: make(chan struct{}),
+		cron: newCronScheduler(filepath.Join(t.TempDir(), cronJobsFile)),
+		now:  clock.Now,
+		newTicker: func(d time.Duration) (<-chan time.Time, func()) {
+			if d != time.Minute {
+				t.Errorf("ticker interval = %s, want 1m", d)
+			}
+			return ticks, func() { close(stopped) }
+		},
+	}
+
+	exited := make(chan struct{})
+	go func() {
+		p.runCronScheduler()
+		close(exited)
+	}()
+
+	ticks <- time.Time{} // no jobs: nothing runs, the loop keeps going
+	close(p.done)
+	<-exited
+	select {
+	case <-stopped:
+	default:
+		t.Error("ticker was not stopped")
+	}
+}
+
+func TestHandleCronAddListRemove(t *testing.T) {
+	dir := t.TempDir()
+	cfg := DefaultConfig()
+	cfg.CronLogDir = dir
+	p, bot := newTestPlugin(cfg)
+	clock := &fakeClock{t: time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)}
+	p.now = clock.Now
+	p.cron = newCronScheduler(filepath.Join(dir, cronJobsFile))
+	owner := &pluginsdk.Message{Type: "private", UserID: 1}
+	other := &pluginsdk.Message{Type: "private", UserID: 2}
+
+	p.handleCron(p.bot, []string{"add", "*/15", "*", "*", "*", "*", "--file", "app.log"}, owner)
+	jobs := p.cron.list(1)
+	if len(jobs) != 1 || jobs[0].Spec != "*/15 * * * *" || jobs[0].Source != "app.log" {
+		t.Fatalf("jobs after add = %+v, want one */15 job on app.log", jobs)
+	}
+	id := jobs[0].ID
+
+	// Jobs survive a restart
+	reloaded := newCronScheduler(filepath.Join(dir, cronJobsFile))
+	if err := reloaded.load(); err != nil || len(reloaded.list(1)) != 1 {
+		t.Fatalf("reloaded jobs = %v, %v; want the added job", reloaded.list(1), err)
+	}
+
+	p.handleCron(p.bot, []string{"list"}, owner)
+	if sent := bot.sent(); !strings.Contains(sent[len(sent)-1].text, id+": [*/15 * * * *] app.log") {
+		t.Errorf("list reply = %q, want job %s", sent[len(sent)-1].text, id)
+	}
+	p.handleCron(p.bot, []string{"list"}, other)
+	if sent := bot.sent(); !strings.Contains(sent[len(sent)-1].text, "no cron jobs") {
+		t.Errorf("other user's list = %q, want none", sent[len(sent)-1].text)
+	}
+
+	// Only the owner can remove a job
+	p.handleCron(p.bot, []string{"remove", strings.ToLower(id)}, other)
+	if len(p.cron.list(1)) != 1 {
+		t.Fatal("another user removed the job")
+	}
+	p.handleCron(p.bot, []string{"remove", strings.ToLower(id)}, owner)
+	if jobs := p.cron.list(1); len(jobs) != 0 {
+		t.Errorf("jobs after remove = %+v, want none", jobs)
+	}
+}
+
+func TestRunCronJobPreparesLog(t *testing.T) {
+	dir := t.TempDir()
+	cli := filepath.Join(dir, "knot-cli")
+	if err := os.WriteFile(cli, []byte("#!/bin/sh\necho done\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	log := "ERROR login failed password=hunter2\n"
+	if err := os.WriteFile(filepath.Join(dir, "app.log"), []byte(log), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("redacted", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Mode = "direct"
+		cfg.KnotCLIPath = cli
+		cfg.SharedDataPath = dir
+		cfg.CronLogDir = dir
+		p, _ := newTestPlugin(cfg)
+
+		p.runCronJob(&CronJob{ID: "J1", Source: "app.log", UserID: 1})
+		p.taskMutex.Lock()
+		var tasks []*TaskStatus
+		for _, task := range p.tasks {
+			tasks = append(tasks, task)
+		}
+		p.taskMutex.Unlock()
+		if len(tasks) != 1 {
+			t.Fatalf("cron job created %d tasks, want 1", len(tasks))
+		}
+		content := tasks[0].logContent
+		if strings.Contains(content, "hunter2") || !strings.Contains(content, redactedSecret) {
+			t.Errorf("task log = %q, want the password redacted", content)
+		}
+
+		// Let the analysis finish writing into the temp dir before it is removed
+		deadline := time.Now().Add(10 * time.Second)
+		for {
+			p.taskMutex.Lock()
+			status := tasks[0].Status
+			p.taskMutex.Unlock()
+			if status == "completed" || status == "failed" {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("task still %s after 10s", status)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.CronLogDir = dir
+		cfg.MaxLogBytes = 8
+		p, bot := newTestPlugin(cfg)
+
+		p.runCronJob(&CronJob{ID: "J2", Source: "app.log", UserID: 1})
+		if len(p.tasks) != 0 {
+			t.Errorf("oversized log started %d tasks, want none", len(p.tasks))
+		}
+		if sent := bot.sent(); len(sent) != 1 || !strings.Contains(sent[0].text, "Scheduled analysis J2 failed") {
+			t.Errorf("replies = %+v, want a cron failure", sent)
+		}
+	})
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// fallbackSharedDataDir is used under the system temp directory when SharedDataPath
+// is not writable and StrictSharedDataPath is off
+const fallbackSharedDataDir = "loganalyzer-shared-data"
+
+// checkWritableDir creates dir if needed and verifies that files can be written in it
+func checkWritableDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	probe, err := os.CreateTemp(dir, ".write-probe-*")
+	if err != nil {
+		return err
+	}
+	name := probe.Name()
+	_, err = probe.WriteString("ok")
+	if closeErr := probe.Close(); err == nil {
+		err = closeErr
+	}
+	os.Remove(name)
+	return err
+}
+
+// ensureSharedDataPath makes sure results can be written before any task runs; it
+// runs on the startup configuration before it is published
+// An unwritable SharedDataPath fails startup in strict mode; otherwise the plugin
+// falls back to a directory under the system temp directory
+func (p *LogAnalyzerPlugin) ensureSharedDataPath(cfg *Config) error {
+	err := checkWritableDir(cfg.SharedDataPath)
+	if err == nil {
+		return nil
+	}
+	if cfg.StrictSharedDataPath {
+		return fmt.Errorf("shared data directory %s is not writable: %v", cfg.SharedDataPath, err)
+	}
+
+	fallback := filepath.Join(os.TempDir(), fallbackSharedDataDir)
+	if fallbackErr := checkWritableDir(fallback); fallbackErr != nil {
+		return fmt.Errorf("shared data directory %s is not writable (%v), neither is fallback %s: %v",
+			cfg.SharedDataPath, err, fallback, fallbackErr)
+	}
+	p.logf("warn", "Shared data directory %s is not writable (%v), using %s instead", cfg.SharedDataPath, err, fallback)
+	cfg.SharedDataPath = fallback
+	return nil
+}
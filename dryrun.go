@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/DaikonSushi/bot-platform/pkg/pluginsdk"
+)
+
+// shellSafeArg matches arguments that need no quoting when shown as a command line
+var shellSafeArg = regexp.MustCompile(`^[A-Za-z0-9_./:=@%+-]+$`)
+
+// isDryRun reports whether a task should only show what would be executed
+func (p *LogAnalyzerPlugin) isDryRun(task *TaskStatus) bool {
+	return task.config.DryRun || task.Options.DryRun
+}
+
+// runDryRun finishes a task by replying with the knot-cli command or proxy request body
+// it would have used, without executing anything or touching the result cache
+func (p *LogAnalyzerPlugin) runDryRun(task *TaskStatus, prompt string, msg *pluginsdk.Message) {
+	var detail string
+	if task.config.Mode == "proxy" {
+		body, err := json.MarshalIndent(p.buildProxyRequest(task, prompt), "", "  ")
+		if err != nil {
+			p.completeTask(task, "", fmt.Errorf("failed to marshal request: %v", err), msg)
+			return
+		}
+		detail = fmt.Sprintf("POST %s/analyze\n%s", strings.Join(task.config.proxyURLs, " | "), body)
+	} else {
+		detail = shellCommand(task.config.KnotCLIPath, p.buildCLIArgs(task, task.config.WorkspacePath, prompt))
+	}
+
+	if !p.finishTask(task, nil) {
+		return
+	}
+	msg = p.deliveryTarget(task, msg)
+	p.replyLong(p.bot, msg, p.msgf("dryrun.title")+"\n━━━━━━━━━━━━━━━━━━━━\n"+p.msgf("label.task_id", task.ID)+"\n\n"+detail)
+}
+
+// shellCommand renders a command line with arguments quoted for a POSIX shell
+func shellCommand(name string, args []string) string {
+	parts := make([]string, 0, len(args)+1)
+	for _, arg := range append([]string{name}, args...) {
+		if shellSafeArg.MatchString(arg) {
+			parts = append(parts, arg)
+		} else {
+			parts = append(parts, "'"+strings.ReplaceAll(arg, "'", `'\''`)+"'")
+		}
+	}
+	return strings.Join(parts, " ")
+}
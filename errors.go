@@ -0,0 +1,93 @@
+package main
+
+import "errors"
+
+// Error categories recorded on failed tasks
+const (
+	errorCategoryTimeout    = "timeout"
+	errorCategoryConnection = "connection"
+	errorCategoryBackend    = "backend"
+	errorCategoryInternal   = "internal"
+)
+
+// Stable error codes recorded on failed and cancelled tasks for programmatic handling
+const (
+	errorCodeTimeout          = "TIMEOUT"
+	errorCodeCancelled        = "CANCELLED"
+	errorCodeStuck            = "STUCK"
+	errorCodeInterrupted      = "INTERRUPTED"
+	errorCodeProxyUnreachable = "PROXY_UNREACHABLE"
+	errorCodeProxyError       = "PROXY_ERROR"
+	errorCodeCLINonzeroExit   = "CLI_NONZERO_EXIT"
+	errorCodeCLITerminated    = "CLI_TERMINATED"
+	errorCodeCLIStartFailed   = "CLI_START_FAILED"
+	errorCodeOutputTooLong    = "OUTPUT_LINE_TOO_LONG"
+	errorCodeConfigInvalid    = "CONFIG_INVALID"
+	errorCodeInternal         = "INTERNAL"
+)
+
+// errAnalysisTimeout is reported when an analysis exceeds its timeout
+var errAnalysisTimeout = errors.New("analysis timed out")
+
+// categorizedError attaches a failure category to an error without changing its message
+type categorizedError struct {
+	category string
+	err      error
+}
+
+func (e *categorizedError) Error() string { return e.err.Error() }
+func (e *categorizedError) Unwrap() error { return e.err }
+
+// withCategory tags err with a failure category
+func withCategory(category string, err error) error {
+	return &categorizedError{category: category, err: err}
+}
+
+// codedError attaches a stable error code to an error without changing its message
+type codedError struct {
+	code string
+	err  error
+}
+
+func (e *codedError) Error() string { return e.err.Error() }
+func (e *codedError) Unwrap() error { return e.err }
+
+// withCode tags err with an error code
+func withCode(code string, err error) error {
+	return &codedError{code: code, err: err}
+}
+
+// errorCode returns the error code of err: an explicit code when tagged, else one
+// derived from the well-known errors and the failure category
+func errorCode(err error) string {
+	var ce *codedError
+	switch {
+	case errors.As(err, &ce):
+		return ce.code
+	case errors.Is(err, errAnalysisTimeout):
+		return errorCodeTimeout
+	case errors.Is(err, errTaskCancelled):
+		return errorCodeCancelled
+	case errors.Is(err, errTaskStuck):
+		return errorCodeStuck
+	}
+	switch errorCategory(err) {
+	case errorCategoryConnection:
+		return errorCodeProxyUnreachable
+	case errorCategoryBackend:
+		return errorCodeProxyError
+	}
+	return errorCodeInternal
+}
+
+// errorCategory returns the failure category of err
+func errorCategory(err error) string {
+	if errors.Is(err, errAnalysisTimeout) {
+		return errorCategoryTimeout
+	}
+	var ce *categorizedError
+	if errors.As(err, &ce) {
+		return ce.category
+	}
+	return errorCategoryInternal
+}
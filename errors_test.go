@@ -0,0 +1,24 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrorCategory(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{fmt.Errorf("knot-cli: %w", errAnalysisTimeout), errorCategoryTimeout},
+		{withCategory(errorCategoryConnection, errors.New("dial tcp: refused")), errorCategoryConnection},
+		{fmt.Errorf("wrapped: %w", withCategory(errorCategoryBackend, errors.New("proxy error"))), errorCategoryBackend},
+		{errors.New("unexpected"), errorCategoryInternal},
+	}
+	for _, tt := range tests {
+		if got := errorCategory(tt.err); got != tt.want {
+			t.Errorf("errorCategory(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// healthCacheTTL is how long a proxy health probe result is reused
+const healthCacheTTL = 10 * time.Second
+
+// healthProbeTimeout bounds a single proxy health probe
+const healthProbeTimeout = 3 * time.Second
+
+// proxyHealth caches the last proxy health probe
+type proxyHealth struct {
+	mu        sync.Mutex
+	checkedAt time.Time
+	err       error
+}
+
+// checkProxyHealth reports whether any proxy instance answers its health endpoint
+// Results are cached for healthCacheTTL so busy chats don't probe on every command
+func (p *LogAnalyzerPlugin) checkProxyHealth() error {
+	if p.cfg().HealthCheckPath == "" {
+		return nil
+	}
+
+	h := &p.health
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.checkedAt.IsZero() && time.Since(h.checkedAt) < healthCacheTTL {
+		return h.err
+	}
+
+	for _, base := range p.cfg().proxyURLs {
+		if h.err = p.probeProxyHealth(proxyEndpoint(base, p.cfg().HealthCheckPath)); h.err == nil {
+			break
+		}
+	}
+	h.checkedAt = time.Now()
+	if h.err != nil {
+		p.logf("warn", "Proxy health check failed: %v", h.err)
+	}
+	return h.err
+}
+
+// probeProxyHealth sends a GET to the health URL and expects a 2xx response
+func (p *LogAnalyzerPlugin) probeProxyHealth(url string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), healthProbeTimeout)
+	defer cancel()
+
+	req, err := p.newProxyRequest(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.doProxyRequest(req)
+	if err != nil {
+		return fmt.Errorf("proxy unreachable: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("proxy health check returned %s", resp.Status)
+	}
+	return nil
+}
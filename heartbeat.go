@@ -0,0 +1,45 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/DaikonSushi/bot-platform/pkg/pluginsdk"
+)
+
+// startHeartbeat posts a progress notice every HeartbeatIntervalSec while a task is running
+// Tasks that finish within the first interval never post; the returned func stops it
+func (p *LogAnalyzerPlugin) startHeartbeat(task *TaskStatus, msg *pluginsdk.Message) func() {
+	if task.config.HeartbeatIntervalSec <= 0 || task.silent || msg == nil {
+		return func() {}
+	}
+
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(time.Duration(task.config.HeartbeatIntervalSec) * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-p.done:
+				return
+			case <-ticker.C:
+				p.taskMutex.RLock()
+				status, started := task.Status, task.RunStartTime
+				p.taskMutex.RUnlock()
+				if isFinished(status) {
+					return
+				}
+				if status != "running" {
+					continue
+				}
+				elapsed := int(time.Since(started).Seconds())
+				p.bot.Reply(msg, pluginsdk.Text(p.msgf("progress.heartbeat", task.ID, elapsed)))
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(stop) }) }
+}
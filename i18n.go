@@ -0,0 +1,540 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// defaultLanguage is used when the configured language or a key is missing
+const defaultLanguage = "en"
+
+// messages holds user-facing reply strings by language and key
+// Values are fmt format strings; analysis content from knot-cli is never translated
+var messages = map[string]map[string]string{
+	"en": {
+		"help.title":    "🔍 Log Analyzer Plugin",
+		"help.intro":    "AI-powered log analysis using knot-cli",
+		"help.mode":     "Mode: %s",
+		"help.commands": helpCommandsEN,
+
+		"status.pending":   "pending",
+		"status.running":   "running",
+		"status.completed": "completed",
+		"status.failed":    "failed",
+		"status.cancelled": "cancelled",
+
+		"label.task_id":    "📋 Task ID: %s",
+		"label.duration":   "⏱️  Duration: %s",
+		"label.running":    "⏱️  Running: %s",
+		"label.status":     "%s Status: %s",
+		"label.error":      "❌ Error: %s",
+		"label.exit_code":  "🔢 Exit Code: %d",
+		"label.error_code": "🏷️ Error Code: %s",
+		"label.queue":      "🔢 Queue: position %d of %d",
+		"label.in_queue":   "#%d of %d in queue",
+
+		"ack.title":      "🔍 Analysis Task Created",
+		"ack.log_length": "📝 Log Length: %d chars",
+		"ack.mode":       "🔧 Mode: %s",
+		"ack.format":     "🧾 Format: %s",
+		"ack.profile":    " (profile: %s)",
+		"ack.queued":     "⏳ Status: Queued for analysis...",
+		"ack.check":      "Use /analyzestatus %s to check progress",
+
+		"progress.heartbeat": "⏳ Still analyzing task %s, elapsed %ds",
+
+		"result.completed":        "✅ Analysis Completed",
+		"result.completed_cached": "✅ Analysis Completed (cached)",
+		"result.failed":           "❌ Analysis Failed",
+
+		"tasks.title":     "📊 Task Status",
+		"tasks.mine":      "📊 Your Analysis Tasks",
+		"tasks.all":       "📊 All Analysis Tasks",
+		"tasks.none":      "📊 You have no analysis tasks",
+		"tasks.showing":   "Showing %d-%d of %d",
+		"tasks.next_page": ", use %s %d",
+
+		"err.task_not_found": "❌ Task not found: %s",
+		"err.admin_only":     "❌ This command is restricted to admins",
+		"err.shutting_down":  "❌ Log analyzer is shutting down, please try again shortly",
+		"err.unavailable":    "❌ Analysis service unavailable, please try again later",
+		"err.cooldown":       "⏳ Backend recently failed, please retry in %ds",
+
+		"label.source":      "📎 Source: %s",
+		"label.ticket":      "🎫 Ticket: %s",
+		"label.tags":        "🏷️ Tags: %s",
+		"label.question":    "❓ Question: %s",
+		"label.instruction": "🧭 Instruction: %s",
+		"label.request_id":  "🔑 Request ID: %s",
+		"label.output_file": "📁 Output File: %s",
+		"label.read_error":  "❌ Read Error: %s",
+		"label.severity":    "%s Severity: %s",
+		"label.undelivered": "⚠️ Result was not delivered, use /analyzeresult %s",
+		"label.user":        "user %d",
+
+		"source.archive": "%s (%d files)",
+		"source.replied": "replied message",
+
+		"ack.truncated":     "⚠️ Note: input appears truncated",
+		"ack.reply_ignored": "ℹ️ Note: inline log used, the replied-to message was ignored",
+
+		"progress.started": "▶️ Your analysis (task %s) has started",
+		"progress.stream":  "🔄 Task %s progress",
+
+		"result.read_failed":     "⚠️ Analysis completed but failed to read result",
+		"result.as_file":         "📎 Full result uploaded as a file",
+		"result.part":            "(part %d/%d)",
+		"result.truncated":       "... [Result truncated, %d characters in total, see full output in file]",
+		"result.injection":       "⚠️ The log contains instruction-like text and was treated as untrusted data",
+		"result.deferred_upload": "📎 Full result will be uploaded at %s (/analyzeresult %s to get it now)",
+
+		"structured.summary":    "📝 Summary",
+		"structured.root_cause": "🔍 Root Cause",
+		"structured.fix":        "🛠️ Suggested Fix",
+
+		"dryrun.title": "🧪 Dry Run (nothing was executed)",
+
+		"cancel.done":       "🛑 Task %s cancelled",
+		"transfer.to":       "📦 Task %s (%s) transferred to ",
+		"transfer.to_user":  "📦 Task %s (%s) transferred to user %d",
+		"transfer.received": "📦 Task %s (%s) has been transferred to you by user %d",
+		"requeue.task":      "🔁 Failed task %s has been requeued as %s",
+		"requeue.done":      "🔁 Requeued %d of %d failed task(s) from the last %s",
+		"warm.started":      "🔥 Warming cache with %d known error(s), results will not be posted",
+		"warm.skipped":      "⚠️ Skipped %d empty or oversized entries",
+
+		"cron.registered": "⏰ Cron Job Registered",
+		"cron.job_id":     "📋 Job ID: %s",
+		"cron.schedule":   "🕒 Schedule: %s",
+		"cron.source":     "📁 Source: %s",
+		"cron.none":       "⏰ You have no cron jobs",
+		"cron.list_title": "⏰ Your Cron Jobs",
+		"cron.removed":    "🗑️ Removed cron job %s",
+
+		"queue.title":        "🚦 Analysis Queue",
+		"queue.slots":        "🎛️ %d/%d slots in use",
+		"queue.running_item": "• %s  user %d  %s  %s",
+		"queue.pending_item": "%d. %s  user %d  waiting %s  %s",
+
+		"stats.title":       "📈 Analysis Stats",
+		"stats.avg":         "⏱️  Avg duration: %s",
+		"stats.p95":         "⏱️  P95 duration: %s",
+		"stats.mode":        "🔧 %s: %d created, %d completed, %d failed (%d timed out), %d cancelled, %.0f%% success",
+		"stats.cache":       "💾 Cache: %d hits, %d misses (%.0f%% hit rate), %d entries",
+		"stats.slots":       "🎛️ Slots in use: %d/%d",
+		"stats.group_slots": "👥 Group slots in use: %s",
+		"stats.none":        "none",
+		"stats.uptime":      "🕐 Uptime: %s",
+
+		"config.title":         "⚙️ Effective Configuration",
+		"config.reloaded":      "🔄 Configuration Reloaded",
+		"config.reloaded_none": "🔄 Configuration reloaded, nothing changed",
+
+		"usage.cancel":      "Usage: /analyzecancel <task_id>",
+		"usage.transfer":    "Usage: /analyzetransfer <task_id> <user_id>",
+		"usage.result":      "Usage: /analyzeresult <task_id>",
+		"usage.get":         "Usage: /analyzeget <task_id>",
+		"usage.status_page": "Usage: /analyzestatus [all] page <n>",
+		"usage.warm":        "Usage: /analyzewarm <file>\nThe file is read from the shared data directory",
+		"usage.requeue":     "Usage: /analyzerequeue --since <duration> [--cause timeout|connection]\nExample: /analyzerequeue --since 30m --cause connection",
+		"usage.cron":        "Usage:\n  /analyzecron add \"<cron>\" --file <source>\n  /analyzecron list\n  /analyzecron remove <job_id>\n\nExample: /analyzecron add \"*/30 * * * *\" --file app/error.log",
+		"usage.cron_remove": "❌ Usage: /analyzecron remove <job_id>",
+
+		"err.generic":                "❌ %v",
+		"err.no_workspace":           "❌ Plugin not properly configured: workspace path not set\nPlease set WORKSPACE_PATH environment variable",
+		"err.no_proxy":               "❌ Plugin not properly configured: proxy URL not set\nPlease set KNOT_PROXY_URL environment variable",
+		"err.no_ticket_webhook":      "❌ Ticket integration not configured\nPlease set LOGANALYZER_TICKET_WEBHOOK environment variable",
+		"err.cache_disabled":         "❌ Result cache is disabled\nPlease set LOGANALYZER_CACHE_TTL_MINUTES environment variable",
+		"err.no_log":                 "❌ Please provide log content to analyze\n\nUsage: /analyze <log_content>\nExample: /analyze [component] sendRequest request: ...",
+		"err.log_too_large":          "❌ Log too large: %d bytes (max %d)",
+		"hint.upload_or_trim":        "Please upload it as a .txt/.log file or trim it to the relevant part",
+		"hint.trim":                  "Please trim it to the relevant part",
+		"err.fetch_url":              "❌ Failed to fetch %s: %v",
+		"err.read_archive":           "❌ Failed to read archive %s: %v",
+		"err.read_attachment":        "❌ Failed to read %s: %v",
+		"err.unsupported_attachment": "❌ Unsupported attachment %s (use .txt, .log, .zip, .tar or .tar.gz)",
+		"err.max_per_user":           "you already have %d analyses in progress, please wait for one to finish",
+		"err.id_in_use":              "task ID %s is already in use",
+		"err.no_such_page":           "❌ Page %d does not exist, you have %d page(s) of tasks",
+		"err.cancel_not_owner":       "❌ Only the task owner or an admin can cancel a task",
+		"err.cancel_finished":        "❌ Task %s already %s, nothing to cancel",
+		"err.cancel_too_late":        "❌ Task %s finished before it could be cancelled",
+		"err.transfer_not_owner":     "❌ Only the task owner or an admin can transfer a task",
+		"err.transfer_same_owner":    "❌ Task %s already belongs to user %d",
+		"err.invalid_user_id":        "❌ Invalid user ID: %s",
+		"err.fetch_not_owner":        "❌ Only the task owner or an admin can fetch this result",
+		"err.no_result_file":         "❌ No result file available for task %s",
+		"err.upload_failed":          "❌ Upload failed: %v",
+		"err.get_not_completed":      "❌ Task %s is %s, only completed tasks have output to fetch",
+		"err.output_expired":         "⌛ The output of task %s has expired and was cleaned up",
+		"err.invalid_duration":       "❌ Invalid duration: %s",
+		"err.unknown_cause":          "❌ Unknown cause: %s (use timeout or connection)",
+		"err.warm_read":              "❌ Failed to read warm file: %v",
+		"err.warm_empty":             "❌ Warm file contains no entries",
+		"err.cron_failed":            "❌ Scheduled analysis %s failed: %v",
+		"err.cron_invalid":           "❌ Invalid cron expression: %v",
+		"err.cron_save":              "❌ Failed to save cron job: %v",
+
+		"err.flag_value":           "flag --%s requires a value",
+		"err.flag_unterminated":    "unterminated quoted value for --%s",
+		"err.unknown_flag":         "unknown flag: --%s",
+		"err.invalid_ticket":       "invalid ticket ID: %q",
+		"err.invalid_model":        "invalid model name: %q",
+		"err.empty_tag":            "tag must not be empty",
+		"err.too_many_tags":        "too many tags (max %d per task)",
+		"err.invalid_temperature":  "invalid temperature: %q",
+		"err.invalid_timeout":      "invalid timeout: %q (seconds)",
+		"err.timeout_too_long":     "timeout %ds exceeds the maximum of %ds",
+		"err.empty_question":       "question must not be empty",
+		"err.empty_instruction":    "instruction must not be empty",
+		"err.instruction_too_long": "instruction is %d characters, the maximum is %d",
+		"err.unknown_name":         "unknown %s %q (available: %s)",
+		"err.unknown_name_none":    "unknown %[1]s %[2]q (no %[1]ss configured)",
+	},
+	"zh": {
+		"help.title":    "🔍 日志分析插件",
+		"help.intro":    "基于 knot-cli 的 AI 日志分析",
+		"help.mode":     "模式: %s",
+		"help.commands": helpCommandsZH,
+
+		"status.pending":   "排队中",
+		"status.running":   "运行中",
+		"status.completed": "已完成",
+		"status.failed":    "失败",
+		"status.cancelled": "已取消",
+
+		"label.task_id":    "📋 任务 ID: %s",
+		"label.duration":   "⏱️  耗时: %s",
+		"label.running":    "⏱️  已运行: %s",
+		"label.status":     "%s 状态: %s",
+		"label.error":      "❌ 错误: %s",
+		"label.exit_code":  "🔢 退出码: %d",
+		"label.error_code": "🏷️ 错误码: %s",
+		"label.queue":      "🔢 队列: 第 %d 位，共 %d 个",
+		"label.in_queue":   "队列第 %d/%d 位",
+
+		"ack.title":      "🔍 已创建分析任务",
+		"ack.log_length": "📝 日志长度: %d 字符",
+		"ack.mode":       "🔧 模式: %s",
+		"ack.format":     "🧾 格式: %s",
+		"ack.profile":    "（提示词: %s）",
+		"ack.queued":     "⏳ 状态: 等待分析...",
+		"ack.check":      "使用 /analyzestatus %s 查看进度",
+
+		"progress.heartbeat": "⏳ 任务 %s 仍在分析中，已用时 %d 秒",
+
+		"result.completed":        "✅ 分析完成",
+		"result.completed_cached": "✅ 分析完成（缓存）",
+		"result.failed":           "❌ 分析失败",
+
+		"tasks.title":     "📊 任务状态",
+		"tasks.mine":      "📊 你的分析任务",
+		"tasks.all":       "📊 全部分析任务",
+		"tasks.none":      "📊 你还没有分析任务",
+		"tasks.showing":   "显示第 %d-%d 个，共 %d 个",
+		"tasks.next_page": "，使用 %s %d 查看下一页",
+
+		"err.task_not_found": "❌ 未找到任务: %s",
+		"err.admin_only":     "❌ 该命令仅限管理员使用",
+		"err.shutting_down":  "❌ 日志分析插件正在关闭，请稍后再试",
+		"err.unavailable":    "❌ 分析服务不可用，请稍后再试",
+		"err.cooldown":       "⏳ 后端刚刚出错，请在 %d 秒后重试",
+
+		"label.source":      "📎 来源: %s",
+		"label.ticket":      "🎫 工单: %s",
+		"label.tags":        "🏷️ 标签: %s",
+		"label.question":    "❓ 问题: %s",
+		"label.instruction": "🧭 指令: %s",
+		"label.request_id":  "🔑 请求 ID: %s",
+		"label.output_file": "📁 输出文件: %s",
+		"label.read_error":  "❌ 读取错误: %s",
+		"label.severity":    "%s 严重程度: %s",
+		"label.undelivered": "⚠️ 结果未送达，请使用 /analyzeresult %s",
+		"label.user":        "用户 %d",
+
+		"source.archive": "%s（%d 个文件）",
+		"source.replied": "被回复的消息",
+
+		"ack.truncated":     "⚠️ 注意: 输入似乎被截断",
+		"ack.reply_ignored": "ℹ️ 注意: 已使用消息中的日志，忽略了被回复的消息",
+
+		"progress.started": "▶️ 你的分析（任务 %s）已开始",
+		"progress.stream":  "🔄 任务 %s 进度",
+
+		"result.read_failed":     "⚠️ 分析已完成，但读取结果失败",
+		"result.as_file":         "📎 完整结果已作为文件上传",
+		"result.part":            "（第 %d/%d 部分）",
+		"result.truncated":       "... [结果已截断，共 %d 个字符，完整输出见文件]",
+		"result.injection":       "⚠️ 日志中包含类似指令的文本，已作为不可信数据处理",
+		"result.deferred_upload": "📎 完整结果将在 %s 上传（使用 /analyzeresult %s 立即获取）",
+
+		"structured.summary":    "📝 摘要",
+		"structured.root_cause": "🔍 根本原因",
+		"structured.fix":        "🛠️ 建议修复",
+
+		"dryrun.title": "🧪 试运行（未执行任何操作）",
+
+		"cancel.done":       "🛑 任务 %s 已取消",
+		"transfer.to":       "📦 任务 %s（%s）已转交给 ",
+		"transfer.to_user":  "📦 任务 %s（%s）已转交给用户 %d",
+		"transfer.received": "📦 用户 %[3]d 将任务 %[1]s（%[2]s）转交给了你",
+		"requeue.task":      "🔁 失败的任务 %s 已重新排队为 %s",
+		"requeue.done":      "🔁 最近 %[3]s 内的 %[2]d 个失败任务中已重新排队 %[1]d 个",
+		"warm.started":      "🔥 正在用 %d 个已知错误预热缓存，结果不会发送",
+		"warm.skipped":      "⚠️ 已跳过 %d 个空的或超大的条目",
+
+		"cron.registered": "⏰ 定时任务已注册",
+		"cron.job_id":     "📋 定时任务 ID: %s",
+		"cron.schedule":   "🕒 计划: %s",
+		"cron.source":     "📁 来源: %s",
+		"cron.none":       "⏰ 你还没有定时任务",
+		"cron.list_title": "⏰ 你的定时任务",
+		"cron.removed":    "🗑️ 已删除定时任务 %s",
+
+		"queue.title":        "🚦 分析队列",
+		"queue.slots":        "🎛️ 已占用 %d/%d 个槽位",
+		"queue.running_item": "• %s  用户 %d  %s  %s",
+		"queue.pending_item": "%d. %s  用户 %d  已等待 %s  %s",
+
+		"stats.title":       "📈 分析统计",
+		"stats.avg":         "⏱️  平均耗时: %s",
+		"stats.p95":         "⏱️  P95 耗时: %s",
+		"stats.mode":        "🔧 %s: 创建 %d，完成 %d，失败 %d（超时 %d），取消 %d，成功率 %.0f%%",
+		"stats.cache":       "💾 缓存: 命中 %d，未命中 %d（命中率 %.0f%%），%d 条",
+		"stats.slots":       "🎛️ 已占用槽位: %d/%d",
+		"stats.group_slots": "👥 群组已占用槽位: %s",
+		"stats.none":        "无",
+		"stats.uptime":      "🕐 运行时间: %s",
+
+		"config.title":         "⚙️ 当前生效配置",
+		"config.reloaded":      "🔄 配置已重新加载",
+		"config.reloaded_none": "🔄 配置已重新加载，没有变化",
+
+		"usage.cancel":      "用法: /analyzecancel <任务ID>",
+		"usage.transfer":    "用法: /analyzetransfer <任务ID> <用户ID>",
+		"usage.result":      "用法: /analyzeresult <任务ID>",
+		"usage.get":         "用法: /analyzeget <任务ID>",
+		"usage.status_page": "用法: /analyzestatus [all] page <页码>",
+		"usage.warm":        "用法: /analyzewarm <文件>\n文件从共享数据目录读取",
+		"usage.requeue":     "用法: /analyzerequeue --since <时长> [--cause timeout|connection]\n示例: /analyzerequeue --since 30m --cause connection",
+		"usage.cron":        "用法:\n  /analyzecron add \"<cron>\" --file <日志源>\n  /analyzecron list\n  /analyzecron remove <定时任务ID>\n\n示例: /analyzecron add \"*/30 * * * *\" --file app/error.log",
+		"usage.cron_remove": "❌ 用法: /analyzecron remove <定时任务ID>",
+
+		"err.generic":                "❌ %v",
+		"err.no_workspace":           "❌ 插件配置不完整: 未设置工作区路径\n请设置 WORKSPACE_PATH 环境变量",
+		"err.no_proxy":               "❌ 插件配置不完整: 未设置代理地址\n请设置 KNOT_PROXY_URL 环境变量",
+		"err.no_ticket_webhook":      "❌ 未配置工单集成\n请设置 LOGANALYZER_TICKET_WEBHOOK 环境变量",
+		"err.cache_disabled":         "❌ 结果缓存未启用\n请设置 LOGANALYZER_CACHE_TTL_MINUTES 环境变量",
+		"err.no_log":                 "❌ 请提供要分析的日志内容\n\n用法: /analyze <日志内容>\n示例: /analyze [component] sendRequest request: ...",
+		"err.log_too_large":          "❌ 日志过大: %d 字节（上限 %d）",
+		"hint.upload_or_trim":        "请以 .txt/.log 文件上传，或只保留相关部分",
+		"hint.trim":                  "请只保留相关部分",
+		"err.fetch_url":              "❌ 获取 %s 失败: %v",
+		"err.read_archive":           "❌ 读取压缩包 %s 失败: %v",
+		"err.read_attachment":        "❌ 读取 %s 失败: %v",
+		"err.unsupported_attachment": "❌ 不支持的附件 %s（请使用 .txt、.log、.zip、.tar 或 .tar.gz）",
+		"err.max_per_user":           "你已有 %d 个分析正在进行，请等待其中一个完成",
+		"err.id_in_use":              "任务 ID %s 已被占用",
+		"err.no_such_page":           "❌ 第 %d 页不存在，你的任务共 %d 页",
+		"err.cancel_not_owner":       "❌ 只有任务所有者或管理员可以取消任务",
+		"err.cancel_finished":        "❌ 任务 %s 已%s，无需取消",
+		"err.cancel_too_late":        "❌ 任务 %s 在取消前已结束",
+		"err.transfer_not_owner":     "❌ 只有任务所有者或管理员可以转交任务",
+		"err.transfer_same_owner":    "❌ 任务 %s 已属于用户 %d",
+		"err.invalid_user_id":        "❌ 无效的用户 ID: %s",
+		"err.fetch_not_owner":        "❌ 只有任务所有者或管理员可以获取该结果",
+		"err.no_result_file":         "❌ 任务 %s 没有可用的结果文件",
+		"err.upload_failed":          "❌ 上传失败: %v",
+		"err.get_not_completed":      "❌ 任务 %s 状态为%s，只有已完成的任务才有可获取的输出",
+		"err.output_expired":         "⌛ 任务 %s 的输出已过期并被清理",
+		"err.invalid_duration":       "❌ 无效的时长: %s",
+		"err.unknown_cause":          "❌ 未知原因: %s（可选 timeout 或 connection）",
+		"err.warm_read":              "❌ 读取预热文件失败: %v",
+		"err.warm_empty":             "❌ 预热文件中没有条目",
+		"err.cron_failed":            "❌ 定时分析 %s 失败: %v",
+		"err.cron_invalid":           "❌ 无效的 cron 表达式: %v",
+		"err.cron_save":              "❌ 保存定时任务失败: %v",
+
+		"err.flag_value":           "参数 --%s 需要一个值",
+		"err.flag_unterminated":    "参数 --%s 的引号未闭合",
+		"err.unknown_flag":         "未知参数: --%s",
+		"err.invalid_ticket":       "无效的工单 ID: %q",
+		"err.invalid_model":        "无效的模型名称: %q",
+		"err.empty_tag":            "标签不能为空",
+		"err.too_many_tags":        "标签过多（每个任务最多 %d 个）",
+		"err.invalid_temperature":  "无效的温度: %q",
+		"err.invalid_timeout":      "无效的超时时间: %q（秒）",
+		"err.timeout_too_long":     "超时时间 %d 秒超过上限 %d 秒",
+		"err.empty_question":       "问题不能为空",
+		"err.empty_instruction":    "指令不能为空",
+		"err.instruction_too_long": "指令长度为 %d 个字符，上限为 %d",
+		"err.unknown_name":         "未知的%s %q（可用: %s）",
+		"err.unknown_name_none":    "未知的%[1]s %[2]q（未配置任何%[1]s）",
+	},
+}
+
+// msgf returns the localized message for key, formatted with args
+// Missing languages and keys fall back to English, then to the key itself
+func (p *LogAnalyzerPlugin) msgf(key string, args ...any) string {
+	format, ok := messages[p.cfg().Language][key]
+	if !ok {
+		if format, ok = messages[defaultLanguage][key]; !ok {
+			format = key
+		}
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// errf returns an error whose text is the localized message for key
+// It is used for errors that are shown to users as-is
+func (p *LogAnalyzerPlugin) errf(key string, args ...any) error {
+	return errors.New(p.msgf(key, args...))
+}
+
+// statusLabel returns the localized name of a task status
+func (p *LogAnalyzerPlugin) statusLabel(status string) string {
+	if _, ok := messages[defaultLanguage]["status."+status]; !ok {
+		return status
+	}
+	return p.msgf("status." + status)
+}
+
+const helpCommandsEN = `Available Commands:
+
+📊 /analyze [options] <log_content>
+   Analyze the given log content using AI
+   The log content should be the error log
+   you want to analyze
+   Attach a .txt/.log file to analyze it
+   Attach a .zip/.tar.gz to analyze all its logs
+   Or reply /analyze to a message to analyze it
+   Options:
+   --ticket <id>  post the result to a ticket
+   --tag <tag>    label the task (repeatable)
+   --temp <t>     model temperature, lower is more deterministic
+   --model <m>    use a different AI model for this task
+   --eli5         explain in plain, non-jargon terms
+   --ask "<q>"    focus the analysis on a question
+   --instruction "<text>"  one-off instruction for this run
+   --id <id>      use an external ID as the task ID
+   --preset <p>   apply a configured option preset
+   --profile <p>  use a configured system prompt
+   --timeout <s>  allow this task more (or less) time
+   --no-codebase  analyze without scanning the codebase
+   --dry-run      show the command instead of running it
+
+📋 /analyzestatus [task_id | page <n>]
+   Check the status of an analysis task
+   Without task_id, lists your most recent tasks
+   all: every user's tasks (admin only)
+
+🛑 /analyzecancel <task_id>
+   Stop a pending or running analysis
+
+📎 /analyzeresult <task_id>
+   Upload a task's full result file now
+
+📥 /analyzeget <task_id>
+   Download a completed task's output again
+
+📦 /analyzetransfer <task_id> <user_id>
+   Hand a task over to another user
+
+⏰ /analyzecron add|list|remove
+   Schedule recurring analysis of a log file
+
+🔁 /analyzerequeue --since <duration> [--cause timeout|connection]
+   Retry failed tasks in a time window (admin)
+
+📈 /analyzestats
+   Task counts, durations and slot usage (admin)
+
+🚦 /analyzequeue
+   Running and waiting tasks with slot usage (admin)
+
+⚙️ /analyzeconfig
+   Show the effective configuration (admin)
+
+🔄 /analyzereload
+   Reload the config file and environment (admin)
+
+🔥 /analyzewarm <file>
+   Pre-analyze known errors into the cache (admin)
+
+❓ /analyzehelp
+   Show this help message
+
+Example:
+  /analyze [component] sendRequest request: ...
+`
+
+const helpCommandsZH = `可用命令:
+
+📊 /analyze [选项] <日志内容>
+   使用 AI 分析给定的日志内容
+   日志内容应为需要分析的错误日志
+   附带 .txt/.log 文件即可分析该文件
+   附带 .zip/.tar.gz 可分析其中所有日志
+   也可以回复某条消息并发送 /analyze
+   选项:
+   --ticket <id>  将结果发布到工单
+   --tag <tag>    为任务添加标签（可重复）
+   --temp <t>     模型温度，越低越确定
+   --model <m>    为该任务使用其他 AI 模型
+   --eli5         用通俗易懂的语言解释
+   --ask "<q>"    围绕某个问题进行分析
+   --instruction "<text>"  仅本次生效的分析指令
+   --id <id>      使用外部 ID 作为任务 ID
+   --preset <p>   应用预设选项
+   --profile <p>  使用指定的系统提示词
+   --timeout <s>  为该任务设置超时时间
+   --no-codebase  不扫描代码库，单独分析日志
+   --dry-run      只显示命令，不实际执行
+
+📋 /analyzestatus [task_id | page <n>]
+   查看分析任务状态
+   不带 task_id 时列出你最近的任务
+   all: 所有用户的任务（仅管理员）
+
+🛑 /analyzecancel <task_id>
+   停止排队中或运行中的分析
+
+📎 /analyzeresult <task_id>
+   立即上传任务的完整结果文件
+
+📥 /analyzeget <task_id>
+   重新下载已完成任务的输出
+
+📦 /analyzetransfer <task_id> <user_id>
+   将任务转交给其他用户
+
+⏰ /analyzecron add|list|remove
+   定时分析日志文件
+
+🔁 /analyzerequeue --since <duration> [--cause timeout|connection]
+   重试时间窗口内失败的任务（管理员）
+
+📈 /analyzestats
+   任务数量、耗时与并发槽使用情况（管理员）
+
+🚦 /analyzequeue
+   运行中与排队中的任务及并发槽占用（管理员）
+
+⚙️ /analyzeconfig
+   查看当前生效的配置（管理员）
+
+🔄 /analyzereload
+   重新加载配置文件与环境变量（管理员）
+
+🔥 /analyzewarm <file>
+   预先分析已知错误并写入缓存（管理员）
+
+❓ /analyzehelp
+   显示本帮助信息
+
+示例:
+  /analyze [component] sendRequest request: ...
+`
@@ -0,0 +1,18 @@
+package main
+
+import "testing"
+
+func TestMessageCatalogsMatch(t *testing.T) {
+	for lang, catalog := range messages {
+		for key := range messages[defaultLanguage] {
+			if _, ok := catalog[key]; !ok {
+				t.Errorf("%s catalog is missing %q", lang, key)
+			}
+		}
+		for key := range catalog {
+			if _, ok := messages[defaultLanguage][key]; !ok {
+				t.Errorf("%s catalog has %q, which is not in the %s catalog", lang, key, defaultLanguage)
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/DaikonSushi/bot-platform/pkg/pluginsdk"
+)
+
+// IDGenerator produces task IDs, e.g. to match an external correlation ID scheme
+type IDGenerator interface {
+	NewID(msg *pluginsdk.Message) string
+}
+
+// shortIDGenerator is the default generator of 8-character random IDs
+type shortIDGenerator struct{}
+
+func (shortIDGenerator) NewID(*pluginsdk.Message) string {
+	return generateShortID()
+}
+
+// prefixIDGenerator prepends a fixed prefix to short random IDs, e.g. "INC-1A2B3C4D"
+type prefixIDGenerator struct {
+	prefix string
+}
+
+func (g prefixIDGenerator) NewID(*pluginsdk.Message) string {
+	return g.prefix + generateShortID()
+}
+
+// newIDGenerator returns the generator for the configured task ID prefix
+func newIDGenerator(prefix string) IDGenerator {
+	if prefix == "" {
+		return shortIDGenerator{}
+	}
+	return prefixIDGenerator{prefix: strings.ToUpper(prefix)}
+}
+
+// taskIDPattern limits explicit task IDs to characters safe in commands and file names
+var taskIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// normalizeTaskID validates an explicit task ID from /analyze --id
+// IDs are upper-cased like generated ones so lookups stay case-insensitive
+func normalizeTaskID(id string) (string, error) {
+	if !taskIDPattern.MatchString(id) {
+		return "", fmt.Errorf("invalid task ID %q: use up to 64 letters, digits, '.', '_' or '-'", id)
+	}
+	return strings.ToUpper(id), nil
+}
+
+// maxIDAttempts bounds regeneration when a generated task ID is already taken
+const maxIDAttempts = 10
+
+// uniqueTaskIDLocked returns a generated ID not used by any known task; taskMutex must be held
+// A generator that keeps colliding (e.g. a deterministic custom one) gets a numeric suffix
+func (p *LogAnalyzerPlugin) uniqueTaskIDLocked(msg *pluginsdk.Message) string {
+	var id string
+	for attempt := 0; attempt < maxIDAttempts; attempt++ {
+		id = p.idGen.NewID(msg)
+		if _, exists := p.tasks[id]; !exists {
+			return id
+		}
+	}
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s-%d", id, n)
+		if _, exists := p.tasks[candidate]; !exists {
+			return candidate
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DaikonSushi/bot-platform/pkg/pluginsdk"
+)
+
+func TestIDGenerators(t *testing.T) {
+	msg := &pluginsdk.Message{Type: "private", UserID: 1}
+
+	id := newIDGenerator("").NewID(msg)
+	if !regexp.MustCompile(`^[0-9A-F]{8}$`).MatchString(id) {
+		t.Errorf("default generator ID = %q, want 8 upper-case hex characters", id)
+	}
+	if other := newIDGenerator("").NewID(msg); other == id {
+		t.Errorf("default generator returned %q twice", id)
+	}
+
+	id = newIDGenerator("inc-").NewID(msg)
+	if !regexp.MustCompile(`^INC-[0-9A-F]{8}$`).MatchString(id) {
+		t.Errorf("prefixed generator ID = %q, want INC- followed by 8 hex characters", id)
+	}
+}
+
+func TestCreateTaskUsesConfiguredGenerator(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.TaskIDPrefix = "ops-"
+	p, _ := newTestPlugin(cfg)
+
+	task, err := p.createUserTask(&pluginsdk.Message{Type: "private", UserID: 1}, AnalyzeOptions{})
+	if err != nil || !strings.HasPrefix(task.ID, "OPS-") {
+		t.Fatalf("createUserTask = %+v, %v; want an OPS- prefixed ID", task, err)
+	}
+	if p.tasks[task.ID] != task {
+		t.Errorf("task %s not registered under its ID", task.ID)
+	}
+}
+
+func TestExplicitTaskID(t *testing.T) {
+	p, _ := newTestPlugin(DefaultConfig())
+	msg := &pluginsdk.Message{Type: "private", UserID: 1}
+
+	opts, _, err := p.parseAnalyzeArgs([]string{"--id", "req-42.a", "log"})
+	if err != nil || opts.ID != "REQ-42.A" {
+		t.Fatalf("parseAnalyzeArgs = %+v, %v; want the ID upper-cased", opts, err)
+	}
+	for _, bad := range []string{"-leading", "has/slash", "has space", strings.Repeat("a", 65)} {
+		if _, _, err := p.parseAnalyzeArgs([]string{"--id=" + bad, "log"}); err == nil {
+			t.Errorf("parseAnalyzeArgs accepted invalid ID %q", bad)
+		}
+	}
+
+	task, err := p.createUserTask(msg, opts)
+	if err != nil || task.ID != "REQ-42.A" {
+		t.Fatalf("createUserTask = %+v, %v; want the explicit ID", task, err)
+	}
+	if dup, err := p.createUserTask(msg, opts); dup != nil || err == nil || !strings.Contains(err.Error(), "already in use") {
+		t.Errorf("colliding ID = %+v, %v; want rejection", dup, err)
+	}
+}
+
+// sequenceIDGenerator returns the given IDs in order, repeating the last one
+type sequenceIDGenerator struct {
+	ids   []string
+	calls int
+}
+
+func (g *sequenceIDGenerator) NewID(*pluginsdk.Message) string {
+	id := g.ids[min(g.calls, len(g.ids)-1)]
+	g.calls++
+	return id
+}
+
+func TestUniqueTaskIDRegeneratesOnCollision(t *testing.T) {
+	gen := &sequenceIDGenerator{ids: []string{"AAAA1111", "AAAA1111", "BBBB2222"}}
+	p := &LogAnalyzerPlugin{
+		tasks: map[string]*TaskStatus{"AAAA1111": {ID: "AAAA1111"}},
+		idGen: gen,
+	}
+
+	if got := p.uniqueTaskIDLocked(&pluginsdk.Message{}); got != "BBBB2222" {
+		t.Errorf("uniqueTaskIDLocked() = %q, want the fresh ID BBBB2222", got)
+	}
+	if gen.calls != 3 {
+		t.Errorf("generator called %d times, want 3", gen.calls)
+	}
+}
+
+func TestUniqueTaskIDSuffixesDeterministicGenerator(t *testing.T) {
+	p := &LogAnalyzerPlugin{
+		tasks: map[string]*TaskStatus{
+			"INC-1":   {ID: "INC-1"},
+			"INC-1-2": {ID: "INC-1-2"},
+		},
+		idGen: &sequenceIDGenerator{ids: []string{"INC-1"}},
+	}
+
+	if got := p.uniqueTaskIDLocked(&pluginsdk.Message{}); got != "INC-1-3" {
+		t.Errorf("uniqueTaskIDLocked() = %q, want INC-1-3", got)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// runJanitor periodically removes old finished tasks and their output files
+func (p *LogAnalyzerPlugin) runJanitor() {
+	if p.cfg().CleanupIntervalMinutes <= 0 || p.cfg().TaskRetentionMinutes <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(p.cfg().CleanupIntervalMinutes) * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case now := <-ticker.C:
+			tasks, files := p.reapTasks(now)
+			if tasks > 0 || files > 0 {
+				p.logf("info", "Janitor removed %d task(s) and %d file(s)", tasks, files)
+			}
+		}
+	}
+}
+
+// reapTasks removes tasks that finished more than TaskRetentionMinutes ago, with their files
+func (p *LogAnalyzerPlugin) reapTasks(now time.Time) (int, int) {
+	cutoff := now.Add(-time.Duration(p.cfg().TaskRetentionMinutes) * time.Minute)
+
+	p.taskMutex.Lock()
+	var expired []*TaskStatus
+	for id, task := range p.tasks {
+		if isFinished(task.Status) && task.EndTime.Before(cutoff) {
+			expired = append(expired, task)
+			delete(p.tasks, id)
+		}
+	}
+	p.taskMutex.Unlock()
+
+	if len(expired) == 0 {
+		return 0, 0
+	}
+	p.schedulePersist()
+
+	files := 0
+	for _, task := range expired {
+		id := task.ID
+		p.uploads.take(id)
+		cache := p.cfg().cache
+		for _, path := range p.taskOutputFiles(task) {
+			if cache != nil {
+				cache.RemoveByPath(path)
+			}
+			if err := os.Remove(path); err == nil {
+				files++
+			} else if !os.IsNotExist(err) {
+				p.taskLogf("warn", id, "Failed to remove %s: %v", path, err)
+			}
+		}
+	}
+	return len(expired), files
+}
+
+// taskOutputFiles returns the output files a task may have written under SharedDataPath
+// Tasks from before OutputPath was recorded used the flat default name
+func (p *LogAnalyzerPlugin) taskOutputFiles(task *TaskStatus) []string {
+	base := filepath.Join(task.config.SharedDataPath, fmt.Sprintf("analysis_%s", task.ID))
+	if task.OutputPath != "" {
+		base = strings.TrimSuffix(task.OutputPath, ".txt")
+	}
+	var paths []string
+	for _, suffix := range []string{".txt", "_redacted.txt", "_annotated_log.txt"} {
+		paths = append(paths, base+suffix)
+	}
+	return paths
+}
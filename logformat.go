@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// Log formats recognized by detectLogFormat; they double as prompt profile names
+const (
+	formatJSON    = "json"
+	formatJava    = "java"
+	formatPython  = "python"
+	formatSyslog  = "syslog"
+	formatAccess  = "access"
+	formatGeneric = "generic"
+)
+
+// formatSampleLines caps how many non-empty lines detection looks at
+const formatSampleLines = 200
+
+var (
+	// javaFramePattern matches stack frames like "at com.example.Foo.bar(Foo.java:42)"
+	javaFramePattern = regexp.MustCompile(`\bat [\w$]+(\.[\w$<>]+)+\([^)]*\)`)
+	// javaExceptionPattern matches exception headers like "java.lang.IllegalStateException:"
+	javaExceptionPattern = regexp.MustCompile(`(Exception in thread "|Caused by: |\b[\w$]+(\.[\w$]+)+(Exception|Error)\b)`)
+	// pythonFramePattern matches traceback frames like `File "app.py", line 12, in main`
+	pythonFramePattern = regexp.MustCompile(`File "[^"]+", line \d+`)
+	// syslogLinePattern matches RFC 3164 ("May  1 12:00:00 host app[1]:") and RFC 5424 ("<34>1 ...") lines
+	syslogLinePattern = regexp.MustCompile(`^(<\d{1,3}>\d? ?)?([A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2} \S+ \S+|\d{4}-\d{2}-\d{2}T\S+ \S+ \S+)`)
+	// accessLinePattern matches nginx/apache common and combined log lines
+	accessLinePattern = regexp.MustCompile(`^\S+ \S+ \S+ \[[^\]]+\] "[A-Z]+ \S+[^"]*" \d{3} `)
+)
+
+// detectLogFormat classifies log content with lightweight heuristics
+// Stack traces win over line formats, since they are usually embedded in other logs;
+// line formats need a majority of the sampled lines to match
+func detectLogFormat(content string) string {
+	if strings.Contains(content, "Traceback (most recent call last)") || pythonFramePattern.MatchString(content) {
+		return formatPython
+	}
+	if javaFramePattern.MatchString(content) && javaExceptionPattern.MatchString(content) {
+		return formatJava
+	}
+
+	var jsonLines, syslogLines, accessLines, total int
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if total++; total > formatSampleLines {
+			total--
+			break
+		}
+		switch {
+		case strings.HasPrefix(line, "{") && json.Valid([]byte(line)):
+			jsonLines++
+		case accessLinePattern.MatchString(line):
+			accessLines++
+		case syslogLinePattern.MatchString(line):
+			syslogLines++
+		}
+	}
+
+	switch {
+	case total == 0:
+		return formatGeneric
+	case jsonLines*2 > total:
+		return formatJSON
+	case accessLines*2 > total:
+		return formatAccess
+	case syslogLines*2 > total:
+		return formatSyslog
+	}
+	return formatGeneric
+}
+
+// autoSelectProfile detects the log format and, when no --profile was given, picks
+// the prompt profile named after it; it returns the detected format ("" when disabled)
+func (p *LogAnalyzerPlugin) autoSelectProfile(opts *AnalyzeOptions, logContent string) string {
+	if !p.cfg().AutoDetectFormat {
+		return ""
+	}
+	format := detectLogFormat(logContent)
+	if opts.Profile == "" {
+		if _, ok := p.cfg().PromptProfiles[format]; ok {
+			opts.Profile = format
+		}
+	}
+	return format
+}
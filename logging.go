@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// logEntry is one plugin log line in json LogFormat
+type logEntry struct {
+	Level    string  `json:"level"`
+	TaskID   string  `json:"task_id,omitempty"`
+	Mode     string  `json:"mode"`
+	Event    string  `json:"event"`
+	Duration float64 `json:"duration_seconds,omitempty"`
+}
+
+// logf logs a plugin-wide event
+func (p *LogAnalyzerPlugin) logf(level, format string, args ...any) {
+	p.emitLog(level, "", 0, fmt.Sprintf(format, args...))
+}
+
+// taskLogf logs an event about one task; text lines are prefixed with "[taskID]"
+func (p *LogAnalyzerPlugin) taskLogf(level, taskID, format string, args ...any) {
+	p.emitLog(level, taskID, 0, fmt.Sprintf(format, args...))
+}
+
+// emitLog writes a log line through bot.Log, as free text or a JSON object per LogFormat
+func (p *LogAnalyzerPlugin) emitLog(level, taskID string, duration time.Duration, event string) {
+	// Startup logs before the first snapshot is published use text
+	if cfg := p.cfg(); cfg != nil && cfg.LogFormat == "json" {
+		data, err := json.Marshal(logEntry{
+			Level:    level,
+			TaskID:   taskID,
+			Mode:     cfg.Mode,
+			Event:    event,
+			Duration: duration.Seconds(),
+		})
+		if err == nil {
+			p.bot.Log(level, string(data))
+			return
+		}
+	}
+
+	if taskID != "" {
+		event = fmt.Sprintf("[%s] %s", taskID, event)
+	}
+	if duration > 0 {
+		event += fmt.Sprintf(" (%s)", duration.Round(time.Millisecond))
+	}
+	p.bot.Log(level, event)
+}
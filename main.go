@@ -6,17 +6,25 @@ package main
 
 import (
 	"bufio"
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/DaikonSushi/bot-platform/pkg/pluginsdk"
 	"github.com/google/uuid"
@@ -29,24 +37,315 @@ type Config struct {
 	// "proxy" - call knot-proxy HTTP service
 	Mode string `json:"mode"`
 
+	// Language selects the reply language ("en", "zh"); missing messages fall back to English
+	// The analysis content itself is not translated (see OutputLang)
+	Language string `json:"language"`
+
+	// LogFormat is "text" or "json"; in json mode plugin log lines are JSON objects
+	// with level, task_id, mode, event and duration_seconds fields
+	LogFormat string `json:"log_format"`
+
+	// OutputFormat is "text" or "json"; in json mode knot-cli returns a structured result
+	// (summary, root cause, suggested fix) that is rendered as sections, falling back to
+	// the raw text when it does not parse
+	OutputFormat string `json:"output_format"`
+
+	// Model selects the AI model: --model in direct mode, "model" in proxy requests
+	// Empty keeps the backend default
+	Model string `json:"model"`
+
 	// Direct mode settings
 	KnotCLIPath      string `json:"knot_cli_path"`
 	WorkspacePath    string `json:"workspace_path"`
 	SystemPromptPath string `json:"system_prompt_path"`
 
+	// WorkspaceIsolation gives each direct-mode task its own directory so concurrent
+	// knot-cli runs do not share temp state: "shared" (default), "tempdir" (private
+	// working/temp dir), "worktree" (git worktree of WorkspacePath) or "copy"
+	WorkspaceIsolation string `json:"workspace_isolation"`
+
+	// ExtraCLIArgs are appended to the knot-cli arguments after the built-in flags
+	// and before the prompt: chat [-w] [--system-prompt] [--model] [--temperature] <extra...> -p <log> [--codebase]
+	ExtraCLIArgs []string `json:"extra_cli_args"`
+
+	// UseCodebase passes --codebase so knot-cli scans the workspace; turn it off (or use
+	// /analyze --no-codebase) for standalone log analysis
+	UseCodebase bool `json:"use_codebase"`
+
 	// Proxy mode settings
+	// ProxyURL may list several comma-separated instances; new tasks rotate across them
+	// and fail over to the next instance when one cannot be reached
 	ProxyURL string `json:"proxy_url"` // e.g., "http://host.docker.internal:9999"
 
+	// ProxyAPIKey is sent on every proxy request, as "Authorization: Bearer <key>" by default
+	// or as the raw key in ProxyAuthHeader when a different header is configured
+	ProxyAPIKey     string `json:"proxy_api_key"`
+	ProxyAuthHeader string `json:"proxy_auth_header"`
+
+	// HealthCheckPath is probed on the proxy before accepting a job (empty disables)
+	HealthCheckPath string `json:"health_check_path"`
+
+	// Status polling backs off exponentially from PollIntervalMs to MaxPollIntervalMs
+	PollIntervalMs    int `json:"poll_interval_ms"`
+	MaxPollIntervalMs int `json:"max_poll_interval_ms"`
+
+	// HTTPRequestTimeoutSec bounds each individual proxy request (submit, status poll,
+	// cancel); the analysis as a whole is still bounded by Timeout (0 = no per-request limit)
+	HTTPRequestTimeoutSec int `json:"http_request_timeout_sec"`
+
+	// MaxPollFailures fails a proxy task after this many consecutive failed status
+	// polls (0 = keep polling until the task timeout)
+	MaxPollFailures int `json:"max_poll_failures"`
+
+	// ProxyIdleConnTimeoutSec closes pooled proxy connections idle for this long
+	// (0 = keep idle connections open indefinitely)
+	ProxyIdleConnTimeoutSec int `json:"proxy_idle_conn_timeout_sec"`
+
 	// Common settings
 	SharedDataPath string `json:"shared_data_path"`
 	MaxConcurrent  int    `json:"max_concurrent"`
 	Timeout        int    `json:"timeout"`
+
+	// StrictSharedDataPath fails startup when SharedDataPath is not writable instead of
+	// falling back to a directory under the system temp directory
+	StrictSharedDataPath bool `json:"strict_shared_data_path"`
+
+	// Per-mode timeouts in seconds, falling back to Timeout when unset
+	TimeoutDirect int `json:"timeout_direct"`
+	TimeoutProxy  int `json:"timeout_proxy"`
+
+	// ShutdownGraceSec is how long OnStop waits for in-flight analyses before cancelling them
+	ShutdownGraceSec int `json:"shutdown_grace_sec"`
+
+	// MaxTimeout is the ceiling for /analyze --timeout overrides in seconds
+	MaxTimeout int `json:"max_timeout"`
+
+	// MaxConcurrentPerGroup caps simultaneous analyses per group (0 = no cap)
+	// Tasks over a group's cap wait even when global slots are free
+	MaxConcurrentPerGroup int `json:"max_concurrent_per_group"`
+
+	// TaskIDPrefix is prepended to generated task IDs, e.g. "INC-"
+	TaskIDPrefix string `json:"task_id_prefix"`
+
+	// MaxPerUser caps a user's pending and running analyses (0 = no cap)
+	// Submissions over the cap are rejected rather than queued
+	MaxPerUser int `json:"max_per_user"`
+
+	// PostFailureCooldownSec briefly rejects new submissions after a backend or
+	// connection failure so the backend can recover (0 = disabled)
+	PostFailureCooldownSec int `json:"post_failure_cooldown_sec"`
+
+	// Output language settings
+	// OutputLang is the default result language, e.g. "English" or "Chinese"
+	// GroupOutputLang overrides it for specific groups (group ID -> language)
+	OutputLang      string           `json:"output_lang"`
+	GroupOutputLang map[int64]string `json:"group_output_lang"`
+
+	// Janitor settings
+	// Finished tasks older than TaskRetentionMinutes are removed with their output files
+	// every CleanupIntervalMinutes (0 disables cleanup)
+	CleanupIntervalMinutes int `json:"cleanup_interval_minutes"`
+	TaskRetentionMinutes   int `json:"task_retention_minutes"`
+
+	// Watchdog settings
+	// Running tasks exceeding Timeout + WatchdogGraceSec are marked failed
+	WatchdogIntervalSec int `json:"watchdog_interval_sec"`
+	WatchdogGraceSec    int `json:"watchdog_grace_sec"`
+
+	// Ticket integration
+	// TicketWebhook is a URL template where "{ticket}" is replaced with the ticket ID
+	// TicketWebhookTemplate is the JSON body template (placeholders: {ticket}, {task_id}, {comment})
+	TicketWebhook         string `json:"ticket_webhook"`
+	TicketWebhookTemplate string `json:"ticket_webhook_template"`
+
+	// WebhookURL receives a JSON POST (task fields, output path, result excerpt)
+	// whenever a task completes or fails; disabled when empty
+	WebhookURL string `json:"webhook_url"`
+
+	// PromptAllowedControlChars lists control characters allowed in the prompt argument
+	// All other knot-cli arguments reject control characters entirely
+	PromptAllowedControlChars string `json:"prompt_allowed_control_chars"`
+
+	// MetricsAddr is the listen address of the metrics HTTP server, e.g. ":9100"
+	// The server is disabled when empty
+	MetricsAddr string `json:"metrics_addr"`
+
+	// APIToken enables GET /tasks/<id> and /tasks/<id>/output on the metrics server
+	// for clients sending "Authorization: Bearer <token>"; disabled when empty
+	APIToken string `json:"api_token"`
+
+	// ShowSeverity renders a severity banner at the top of results when one is found
+	ShowSeverity bool `json:"show_severity"`
+
+	// CronLogDir is the directory scheduled analyses may read log sources from
+	// Scheduled analysis is disabled when empty
+	CronLogDir string `json:"cron_log_dir"`
+
+	// DedupStackFrames collapses stack frames repeated across sections of multi-source logs
+	DedupStackFrames bool `json:"dedup_stack_frames"`
+
+	// RedactSecrets masks credentials (bearer tokens, passwords, AWS keys, JWTs, private keys)
+	// in submitted logs before they are sent anywhere or written to disk
+	// SecretPatterns adds regexes whose whole match is masked
+	RedactSecrets  bool     `json:"redact_secrets"`
+	SecretPatterns []string `json:"secret_patterns"`
+
+	// Result host redaction
+	// RedactHostPattern overrides the default internal IP regex
+	// RedactDomainSuffix additionally matches hostnames ending in it, e.g. "corp.example.com"
+	RedactHostsInResult bool   `json:"redact_hosts_in_result"`
+	RedactHostPattern   string `json:"redact_host_pattern"`
+	RedactDomainSuffix  string `json:"redact_domain_suffix"`
+
+	// MaxReplyChars bounds a single chat message; longer status replies are split
+	// and longer results are truncated (the full result is uploaded as a file)
+	MaxReplyChars int `json:"max_reply_chars"`
+	// ReplyMode controls long results: "truncate" (inline preview plus file),
+	// "split" (several numbered messages) or "file" (file upload only)
+	ReplyMode string `json:"reply_mode"`
+
+	// OutputPathTemplate names result files relative to SharedDataPath, e.g.
+	// "{group}/analysis_{id}_{date}.txt"; placeholders: {id}, {group}, {user}, {date}
+	OutputPathTemplate string `json:"output_path_template"`
+
+	// DryRun replies with the knot-cli command or proxy request body instead of running
+	// the analysis (also available per request with /analyze --dry-run)
+	DryRun bool `json:"dry_run"`
+
+	// StatusPageSize is how many tasks /analyzestatus lists per page (0 = all)
+	StatusPageSize int `json:"status_page_size"`
+
+	// Tag limits for /analyze --tag; over-long tags are truncated
+	MaxTagsPerTask int `json:"max_tags_per_task"`
+	MaxTagLength   int `json:"max_tag_length"`
+
+	// MaxInstructionChars caps /analyze --instruction; longer instructions are rejected (0 = no cap)
+	MaxInstructionChars int `json:"max_instruction_chars"`
+
+	// StreamToChat posts partial output as progress replies while the task runs (direct mode)
+	// Posting is append-only: each reply carries the output added since the previous one,
+	// at most once per StreamPostIntervalSec
+	// StreamEveryLines also triggers a post after that many new lines (0 disables)
+	StreamToChat          bool `json:"stream_to_chat"`
+	StreamPostIntervalSec int  `json:"stream_post_interval_sec"`
+	StreamEveryLines      int  `json:"stream_every_lines"`
+
+	// AdminUserIDs may run admin-only commands
+	AdminUserIDs []int64 `json:"admin_user_ids"`
+
+	// Result cache for identical submissions (disabled when CacheTTLMinutes is 0)
+	CacheTTLMinutes int `json:"cache_ttl_minutes"`
+	CacheMaxEntries int `json:"cache_max_entries"`
+
+	// MaxCachedResultBytes bounds results held in cache memory; larger results are
+	// cached by output file path and re-read on a hit (0 = no limit)
+	MaxCachedResultBytes int `json:"max_cached_result_bytes"`
+
+	// Model temperature; DefaultTemperature is used when /analyze --temp is not given
+	// and left to the backend when nil. Requested values are clamped to the range.
+	DefaultTemperature *float64 `json:"default_temperature"`
+	MinTemperature     float64  `json:"min_temperature"`
+	MaxTemperature     float64  `json:"max_temperature"`
+
+	// MaxAttachmentBytes caps .txt/.log attachments used as analysis input
+	MaxAttachmentBytes int64 `json:"max_attachment_bytes"`
+
+	// FetchAllowedHosts restricts which hosts /analyze <url> may fetch from (subdomains
+	// included); empty disables fetching
+	FetchAllowedHosts []string `json:"fetch_allowed_hosts"`
+
+	// MaxOutputBytes caps the direct-mode output file; past it knot-cli is stopped and
+	// the task completes with what was captured (0 = no limit)
+	MaxOutputBytes int `json:"max_output_bytes"`
+
+	// MaxLineBytes is the longest single knot-cli output line read in direct mode
+	// (minified JSON can be long); a longer line fails the task
+	MaxLineBytes int `json:"max_line_bytes"`
+
+	// MaxLogBytes caps the log sent for analysis, in bytes, whatever its source
+	// (inline, attachment, archive or replied message); 0 disables the check
+	MaxLogBytes int `json:"max_log_bytes"`
+
+	// Archive attachment limits (zip, tar, tar.gz)
+	// ArchiveMaxBytes bounds both the download and the total extracted text
+	ArchiveMaxEntries int   `json:"archive_max_entries"`
+	ArchiveMaxBytes   int64 `json:"archive_max_bytes"`
+
+	// AnnotateSourceLog uploads the submitted log with markers on the lines the result
+	// references (by line number or quoted snippet) alongside the full result file
+	AnnotateSourceLog bool `json:"annotate_source_log"`
+
+	// HighlightDiffs wraps unified diffs in results in ```diff fences
+	HighlightDiffs bool `json:"highlight_diffs"`
+
+	// ReplyStyle renders replies as "plain" text with dividers or "markdown"
+	// (bold headers, metadata lists, log and code sections in fences)
+	ReplyStyle string `json:"reply_style"`
+
+	// StripANSI removes ANSI escape sequences (colors) from results and output files
+	StripANSI bool `json:"strip_ansi"`
+
+	// ELI5Suffix is appended to the prompt for /analyze --eli5
+	ELI5Suffix string `json:"eli5_suffix"`
+
+	// DeferLargeUploads holds full-result file uploads until QuietHours (e.g. "22:00-07:00")
+	// Users can fetch a deferred file immediately with /analyzeresult
+	DeferLargeUploads bool   `json:"defer_large_uploads"`
+	QuietHours        string `json:"quiet_hours"`
+
+	// Presets bundle /analyze options under a name, used with --preset <name>
+	// Only settable from the config file
+	Presets map[string]AnalyzeOptions `json:"presets"`
+
+	// PromptProfiles maps names to system prompt files, selected with /analyze --profile <name>
+	// Direct mode passes the file as --system-prompt; proxy mode sends the name for the
+	// proxy to resolve. Only settable from the config file
+	PromptProfiles map[string]string `json:"prompt_profiles"`
+
+	// AutoDetectFormat classifies submitted logs (json, java, python, syslog, access, generic)
+	// and uses the prompt profile of the same name when no --profile is given
+	AutoDetectFormat bool `json:"auto_detect_format"`
+
+	// EmphasizeRecent appends the RecentEntryLines most recent log lines (by timestamp
+	// when present, else line order) as a delimited section the model should weigh most
+	EmphasizeRecent  bool `json:"emphasize_recent"`
+	RecentEntryLines int  `json:"recent_entry_lines"`
+
+	// GuardPromptInjection wraps logs containing instruction-hijacking phrases in an
+	// untrusted-data block and flags the result
+	GuardPromptInjection bool `json:"guard_prompt_injection"`
+
+	// DeliveryTimeoutSec bounds sending a completed result (reply and upload)
+	// Results that miss it are marked undelivered (0 = no limit)
+	DeliveryTimeoutSec int `json:"delivery_timeout_sec"`
+
+	// NotifyOnStart sends a short notice when a queued task starts running
+	NotifyOnStart bool `json:"notify_on_start"`
+
+	// HeartbeatIntervalSec posts "still analyzing" to the chat at this interval while a
+	// task runs (0 = off)
+	HeartbeatIntervalSec int `json:"heartbeat_interval_sec"`
+
+	// State derived from the settings above by buildDerivedState; a published Config
+	// is never modified, so tasks can keep the snapshot they started with
+	hostRedactor *regexp.Regexp
+	secretRules  []secretRule
+	quietHours   *quietWindow
+	cache        *resultCache
+	semaphore    chan struct{}
+	proxyURLs    []string
+	httpClient   *http.Client
 }
 
 // ProxyAnalyzeRequest is the request body for proxy mode
 type ProxyAnalyzeRequest struct {
-	RequestID  string `json:"request_id"`
-	LogContent string `json:"log_content"`
+	RequestID    string   `json:"request_id"`
+	LogContent   string   `json:"log_content"`
+	Temperature  *float64 `json:"temperature,omitempty"`
+	Model        string   `json:"model,omitempty"`
+	OutputFormat string   `json:"output_format,omitempty"`
+	Profile      string   `json:"profile,omitempty"`
+	Instruction  string   `json:"instruction,omitempty"`
 }
 
 // ProxyAnalyzeResponse is the response from proxy service
@@ -67,28 +366,94 @@ type ProxyStatusResponse struct {
 	Error       string  `json:"error,omitempty"`
 	Content     string  `json:"content,omitempty"`
 	ContentSize int     `json:"content_size,omitempty"`
+	Severity    string  `json:"severity,omitempty"`
 }
 
 // TaskStatus represents the status of an analysis task
 type TaskStatus struct {
-	ID        string    `json:"id"`
-	Status    string    `json:"status"` // "pending", "running", "completed", "failed"
-	StartTime time.Time `json:"start_time"`
-	EndTime   time.Time `json:"end_time,omitempty"`
-	Duration  string    `json:"duration,omitempty"`
-	Error     string    `json:"error,omitempty"`
-	UserID    int64     `json:"user_id"`
-	GroupID   int64     `json:"group_id"`
+	ID            string    `json:"id"`
+	Status        string    `json:"status"` // "pending", "running", "completed", "failed", "cancelled"
+	StartTime     time.Time `json:"start_time"`
+	RunStartTime  time.Time `json:"run_start_time,omitempty"`
+	EndTime       time.Time `json:"end_time,omitempty"`
+	Duration      string    `json:"duration,omitempty"`
+	Error         string    `json:"error,omitempty"`
+	ErrorCategory string    `json:"error_category,omitempty"` // "timeout", "connection", "backend", "internal"
+	ErrorCode     string    `json:"error_code,omitempty"`     // stable code such as "TIMEOUT", see errorCode
+	ExitCode      int       `json:"exit_code,omitempty"`      // non-zero knot-cli exit code (direct mode)
+	UserID        int64     `json:"user_id"`
+	GroupID       int64     `json:"group_id"`
+	Severity      string    `json:"severity,omitempty"`
+	Source        string    `json:"source,omitempty"` // attachment the log came from
+	OutputPath    string    `json:"output_path,omitempty"`
+	LogFormat     string    `json:"log_format,omitempty"` // detected format, see detectLogFormat
+	Mode          string    `json:"mode,omitempty"`       // "direct" or "proxy", fixed when the task is created
+
+	// SlotAcquiredTime is when the task obtained a concurrency slot, after any queueing
+	SlotAcquiredTime time.Time `json:"slot_acquired_time,omitempty"`
+
+	InputTruncated     bool   `json:"input_truncated,omitempty"`
+	InjectionSuspected bool   `json:"injection_suspected,omitempty"`
+	Cached             bool   `json:"cached,omitempty"`
+	RetryOf            string `json:"retry_of,omitempty"`
+	TransferredFrom    int64  `json:"transferred_from,omitempty"`
+	RequeuedAs         string `json:"requeued_as,omitempty"`
+	Undelivered        bool   `json:"undelivered,omitempty"` // result delivery timed out
+
+	Options AnalyzeOptions `json:"options"`
+
+	config     *Config            // configuration snapshot the task was created with
+	msg        *pluginsdk.Message // message to deliver results to
+	logContent string             // submitted log, kept for requeueing
+	cacheKey   string             // result cache key, set once the prompt is built
+	resultPath string             // result file safe to share (redacted when enabled)
+	silent     bool               // results are cached but never posted (cache warming)
+	release    func()             // releases the held concurrency slot, safe to call repeatedly
+	cancel     func()             // stops the running analysis, set once it starts
+	waiting    bool               // waiting for a concurrency slot
+	proxyURL   string             // proxy instance that accepted the task
+	queued     bool               // waited for a concurrency slot
 }
 
 // LogAnalyzerPlugin provides AI-powered log analysis using knot-cli
 type LogAnalyzerPlugin struct {
-	bot        *pluginsdk.BotClient
-	config     Config
-	tasks      map[string]*TaskStatus
-	taskMutex  sync.RWMutex
-	semaphore  chan struct{}
-	httpClient *http.Client
+	bot       *pluginsdk.BotClient
+	tasks     map[string]*TaskStatus
+	taskMutex sync.RWMutex
+	health    proxyHealth
+	proxyNext atomic.Uint64
+
+	// config is the current configuration snapshot, replaced whole by /analyzereload
+	config atomic.Pointer[Config]
+	// reloadMutex serializes reloads so each builds on the snapshot it replaces
+	reloadMutex sync.Mutex
+
+	groupSlots      map[int64]chan struct{}
+	groupSlotsMutex sync.Mutex
+
+	// backendCooldownUntil rejects new submissions after a backend failure (guarded by taskMutex)
+	backendCooldownUntil time.Time
+
+	done      chan struct{}
+	persistCh chan struct{}
+	startedAt time.Time
+
+	// tasksCtx is the parent of every analysis context; OnStop cancels it
+	// once in-flight analyses (tracked by running) had ShutdownGraceSec to finish
+	tasksCtx    context.Context
+	cancelTasks context.CancelFunc
+	running     sync.WaitGroup
+	stopping    atomic.Bool
+
+	metrics       *Metrics
+	metricsServer *http.Server
+	cron          *cronScheduler
+	idGen         IDGenerator
+	uploads       *uploadQueue
+
+	// now and newTicker drive the cron and upload schedulers; tests replace them with a fake clock
+	now       func() time.Time
+	newTicker func(d time.Duration) (<-chan time.Time, func())
 }
 
 // DefaultConfig returns default configuration
@@ -101,6 +466,68 @@ func DefaultConfig() Config {
 		SharedDataPath: "/shared-data",
 		MaxConcurrent:  3,
 		Timeout:        300, // 5 minutes
+
+		HealthCheckPath:         "/health",
+		ProxyIdleConnTimeoutSec: 90,
+		PollIntervalMs:          500,
+		MaxPollIntervalMs:       5000,
+		MaxPollFailures:         5,
+		HTTPRequestTimeoutSec:   10,
+
+		MaxTimeout:       1800,
+		ShutdownGraceSec: 30,
+
+		WatchdogIntervalSec: 60,
+		WatchdogGraceSec:    60,
+
+		CleanupIntervalMinutes: 60,
+		TaskRetentionMinutes:   7 * 24 * 60,
+
+		PromptAllowedControlChars: "\n\r\t",
+
+		MaxTagsPerTask: 5,
+		MaxTagLength:   32,
+
+		MaxInstructionChars: 500,
+
+		StreamPostIntervalSec: 30,
+
+		MaxReplyChars: 3000,
+		ReplyMode:     "truncate",
+		ReplyStyle:    replyStylePlain,
+		OutputFormat:  "text",
+		LogFormat:     "text",
+		Language:      "en",
+
+		StatusPageSize: 10,
+
+		DeliveryTimeoutSec: 120,
+
+		RecentEntryLines: 50,
+
+		CacheMaxEntries:      100,
+		MaxCachedResultBytes: 64 * 1024,
+
+		MinTemperature: 0,
+		MaxTemperature: 1,
+
+		ArchiveMaxEntries: 20,
+		ArchiveMaxBytes:   256 * 1024,
+
+		MaxAttachmentBytes: 512 * 1024,
+		MaxLogBytes:        64 * 1024,
+		MaxOutputBytes:     10 * 1024 * 1024,
+		MaxLineBytes:       1024 * 1024,
+
+		RedactSecrets: true,
+		UseCodebase:   true,
+		StripANSI:     true,
+
+		WorkspaceIsolation: workspaceShared,
+
+		AutoDetectFormat: true,
+
+		ELI5Suffix: "Explain the root cause and the fix in plain, non-technical language that someone new to this system can follow. Avoid jargon, and define any technical term you must use.",
 	}
 }
 
@@ -111,7 +538,7 @@ func (p *LogAnalyzerPlugin) Info() pluginsdk.PluginInfo {
 		Version:           "1.1.0",
 		Description:       "AI-powered log analysis plugin using knot-cli (supports proxy mode for Docker)",
 		Author:            "hovanzhang",
-		Commands:          []string{"analyze", "analyzestatus", "analyzehelp"},
+		Commands:          []string{"analyze", "analyzestatus", "analyzehelp", "analyzecron", "analyzerequeue", "analyzewarm", "analyzetransfer", "analyzeresult", "analyzecancel", "analyzestats", "analyzeconfig", "analyzereload", "analyzeget", "analyzequeue"},
 		HandleAllMessages: false,
 	}
 }
@@ -119,57 +546,493 @@ func (p *LogAnalyzerPlugin) Info() pluginsdk.PluginInfo {
 // OnStart is called when the plugin starts
 func (p *LogAnalyzerPlugin) OnStart(bot *pluginsdk.BotClient) error {
 	p.bot = bot
+	p.startedAt = time.Now()
 	p.tasks = make(map[string]*TaskStatus)
+	p.groupSlots = make(map[int64]chan struct{})
+	p.done = make(chan struct{})
+	p.tasksCtx, p.cancelTasks = context.WithCancel(context.Background())
+	p.persistCh = make(chan struct{}, 1)
+	p.metrics = NewMetrics(func() int { return len(p.globalSemaphore()) })
+
+	// Load configuration from defaults, then the optional config file, then environment
+	cfg := p.loadConfig()
+	p.buildDerivedState(&cfg, nil)
+
+	// Ensure the shared data directory exists and is writable
+	if err := p.ensureSharedDataPath(&cfg); err != nil {
+		return err
+	}
+	p.config.Store(&cfg)
+
+	if p.idGen == nil {
+		p.idGen = newIDGenerator(p.cfg().TaskIDPrefix)
+	}
+	p.uploads = newUploadQueue()
 
-	// Load configuration from environment or use defaults
-	p.config = DefaultConfig()
+	p.logf("info", "Log analyzer plugin started in %s mode", p.cfg().Mode)
+	if p.cfg().Mode == "proxy" {
+		p.logf("info", "  proxy_url: %s", p.cfg().ProxyURL)
+	} else {
+		p.logf("info", "  workspace: %s", p.cfg().WorkspacePath)
+	}
+	p.logf("info", "  shared_data: %s", p.cfg().SharedDataPath)
+
+	// Restore task history from the previous run
+	if err := p.loadTasks(); err != nil {
+		p.logf("warn", "Failed to load tasks: %v", err)
+	}
+	go p.runPersister()
+
+	// Start watchdog for stuck tasks and the janitor for old ones
+	go p.runWatchdog()
+	go p.runJanitor()
+
+	// Load scheduled analyses and start the scheduler
+	p.cron = newCronScheduler(filepath.Join(p.cfg().SharedDataPath, cronJobsFile))
+	if err := p.cron.load(); err != nil {
+		p.logf("warn", "Failed to load cron jobs: %v", err)
+	}
+	go p.runCronScheduler()
+
+	// Always running, since /analyzereload can turn on deferral or set quiet hours later
+	go p.runUploadScheduler()
+
+	if p.cfg().MetricsAddr != "" {
+		p.startMetricsServer()
+	}
+
+	return nil
+}
+
+// loadConfig builds the configuration from defaults, then the optional config file, then
+// environment variables, and validates it
+func (p *LogAnalyzerPlugin) loadConfig() Config {
+	cfg := DefaultConfig()
+	if path := os.Getenv("LOGANALYZER_CONFIG"); path != "" {
+		if err := loadConfigFile(path, &cfg); err != nil {
+			p.logf("warn", "Ignoring config file: %v", err)
+		}
+	}
 
 	// Override from environment variables if set
 	if v := os.Getenv("LOGANALYZER_MODE"); v != "" {
-		p.config.Mode = v
+		cfg.Mode = v
 	}
 	if v := os.Getenv("KNOT_CLI_PATH"); v != "" {
-		p.config.KnotCLIPath = v
+		cfg.KnotCLIPath = v
 	}
 	if v := os.Getenv("WORKSPACE_PATH"); v != "" {
-		p.config.WorkspacePath = v
+		cfg.WorkspacePath = v
+	}
+	if v := os.Getenv("WORKSPACE_ISOLATION"); v != "" {
+		cfg.WorkspaceIsolation = v
 	}
 	if v := os.Getenv("SYSTEM_PROMPT_PATH"); v != "" {
-		p.config.SystemPromptPath = v
+		cfg.SystemPromptPath = v
+	}
+	if v := os.Getenv("LOGANALYZER_LANGUAGE"); v != "" {
+		cfg.Language = v
+	}
+	if v := os.Getenv("LOGANALYZER_LOG_FORMAT"); v != "" {
+		cfg.LogFormat = v
+	}
+	if v := os.Getenv("LOGANALYZER_OUTPUT_FORMAT"); v != "" {
+		cfg.OutputFormat = v
+	}
+	if v := os.Getenv("LOGANALYZER_MODEL"); v != "" {
+		cfg.Model = v
+	}
+	if v := os.Getenv("KNOT_USE_CODEBASE"); v != "" {
+		cfg.UseCodebase, _ = strconv.ParseBool(v)
+	}
+	if v := os.Getenv("KNOT_EXTRA_ARGS"); v != "" {
+		cfg.ExtraCLIArgs = strings.Fields(v)
 	}
 	if v := os.Getenv("KNOT_PROXY_URL"); v != "" {
-		p.config.ProxyURL = v
+		cfg.ProxyURL = v
+	}
+	if v := os.Getenv("KNOT_PROXY_API_KEY"); v != "" {
+		cfg.ProxyAPIKey = v
+	}
+	if v := os.Getenv("KNOT_PROXY_AUTH_HEADER"); v != "" {
+		cfg.ProxyAuthHeader = v
+	}
+	if v, ok := os.LookupEnv("KNOT_HEALTH_CHECK_PATH"); ok {
+		cfg.HealthCheckPath = v
 	}
 	if v := os.Getenv("SHARED_DATA_PATH"); v != "" {
-		p.config.SharedDataPath = v
+		cfg.SharedDataPath = v
+	}
+	if v := os.Getenv("LOGANALYZER_STRICT_SHARED_DATA_PATH"); v != "" {
+		cfg.StrictSharedDataPath, _ = strconv.ParseBool(v)
+	}
+	if v := os.Getenv("LOGANALYZER_OUTPUT_PATH_TEMPLATE"); v != "" {
+		cfg.OutputPathTemplate = v
+	}
+	if v := os.Getenv("LOGANALYZER_TIMEOUT_DIRECT"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.TimeoutDirect = n
+		}
+	}
+	if v := os.Getenv("LOGANALYZER_TIMEOUT_PROXY"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.TimeoutProxy = n
+		}
+	}
+	if v := os.Getenv("LOGANALYZER_SHUTDOWN_GRACE_SEC"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.ShutdownGraceSec = n
+		}
+	}
+	if v := os.Getenv("LOGANALYZER_MAX_TIMEOUT"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.MaxTimeout = n
+		}
+	}
+	if v := os.Getenv("KNOT_POLL_INTERVAL_MS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.PollIntervalMs = n
+		}
+	}
+	if v := os.Getenv("KNOT_MAX_POLL_INTERVAL_MS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.MaxPollIntervalMs = n
+		}
+	}
+	if v := os.Getenv("KNOT_HTTP_REQUEST_TIMEOUT"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.HTTPRequestTimeoutSec = n
+		}
+	}
+	if v := os.Getenv("KNOT_MAX_POLL_FAILURES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.MaxPollFailures = n
+		}
+	}
+	if v := os.Getenv("LOGANALYZER_PROXY_IDLE_CONN_TIMEOUT"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.ProxyIdleConnTimeoutSec = n
+		}
+	}
+	if v := os.Getenv("LOGANALYZER_TASK_ID_PREFIX"); v != "" {
+		cfg.TaskIDPrefix = v
+	}
+	// LOGANALYZER_MAX_CONCURRENT_PER_USER is the older name of LOGANALYZER_MAX_PER_USER
+	for _, name := range []string{"LOGANALYZER_MAX_CONCURRENT_PER_USER", "LOGANALYZER_MAX_PER_USER"} {
+		if v := os.Getenv(name); v != "" {
+			if n, err := strconv.Atoi(v); err == nil {
+				cfg.MaxPerUser = n
+			}
+		}
+	}
+	if v := os.Getenv("LOGANALYZER_POST_FAILURE_COOLDOWN"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.PostFailureCooldownSec = n
+		}
+	}
+	if v := os.Getenv("LOGANALYZER_MAX_CONCURRENT_PER_GROUP"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.MaxConcurrentPerGroup = n
+		}
+	}
+	if v := os.Getenv("LOGANALYZER_CLEANUP_INTERVAL_MINUTES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.CleanupIntervalMinutes = n
+		}
+	}
+	if v := os.Getenv("LOGANALYZER_TASK_RETENTION_MINUTES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.TaskRetentionMinutes = n
+		}
+	}
+	if v := os.Getenv("LOGANALYZER_ADMIN_IDS"); v != "" {
+		cfg.AdminUserIDs = parseIDList(v)
+	}
+	if v := os.Getenv("LOGANALYZER_CACHE_TTL_MINUTES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.CacheTTLMinutes = n
+		}
+	}
+	if v := os.Getenv("LOGANALYZER_MAX_ATTACHMENT_BYTES"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			cfg.MaxAttachmentBytes = n
+		}
+	}
+	if v := os.Getenv("LOGANALYZER_FETCH_ALLOWED_HOSTS"); v != "" {
+		cfg.FetchAllowedHosts = parseList(v)
+	}
+	if v := os.Getenv("LOGANALYZER_MAX_OUTPUT_BYTES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.MaxOutputBytes = n
+		}
+	}
+	if v := os.Getenv("LOGANALYZER_MAX_LINE_BYTES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.MaxLineBytes = n
+		}
+	}
+	if v := os.Getenv("LOGANALYZER_MAX_LOG_BYTES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.MaxLogBytes = n
+		}
+	}
+	if v := os.Getenv("LOGANALYZER_MAX_CACHED_RESULT_BYTES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.MaxCachedResultBytes = n
+		}
+	}
+	if v := os.Getenv("LOGANALYZER_DEFAULT_TEMPERATURE"); v != "" {
+		if t, err := strconv.ParseFloat(v, 64); err == nil {
+			cfg.DefaultTemperature = &t
+		}
+	}
+	if v := os.Getenv("LOGANALYZER_OUTPUT_LANG"); v != "" {
+		cfg.OutputLang = v
+	}
+	if v := os.Getenv("LOGANALYZER_GROUP_OUTPUT_LANG"); v != "" {
+		cfg.GroupOutputLang = parseGroupMap(v)
+	}
+	if v := os.Getenv("LOGANALYZER_TICKET_WEBHOOK"); v != "" {
+		cfg.TicketWebhook = v
+	}
+	if v := os.Getenv("LOGANALYZER_TICKET_WEBHOOK_TEMPLATE"); v != "" {
+		cfg.TicketWebhookTemplate = v
+	}
+	if v := os.Getenv("LOGANALYZER_WEBHOOK_URL"); v != "" {
+		cfg.WebhookURL = v
+	}
+	if v := os.Getenv("LOGANALYZER_API_TOKEN"); v != "" {
+		cfg.APIToken = v
+	}
+	if v := os.Getenv("LOGANALYZER_METRICS_ADDR"); v != "" {
+		cfg.MetricsAddr = v
+	}
+	if v := os.Getenv("LOGANALYZER_MAX_REPLY_CHARS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.MaxReplyChars = n
+		}
+	}
+	if v := os.Getenv("LOGANALYZER_DRY_RUN"); v != "" {
+		cfg.DryRun, _ = strconv.ParseBool(v)
+	}
+	if v := os.Getenv("LOGANALYZER_STATUS_PAGE_SIZE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.StatusPageSize = n
+		}
+	}
+	if v := os.Getenv("LOGANALYZER_REPLY_MODE"); v != "" {
+		cfg.ReplyMode = v
+	}
+	if v := os.Getenv("LOGANALYZER_REPLY_STYLE"); v != "" {
+		cfg.ReplyStyle = v
+	}
+	if v := os.Getenv("LOGANALYZER_MAX_INSTRUCTION_CHARS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.MaxInstructionChars = n
+		}
+	}
+	if v := os.Getenv("LOGANALYZER_SHOW_SEVERITY"); v != "" {
+		cfg.ShowSeverity, _ = strconv.ParseBool(v)
+	}
+	if v := os.Getenv("LOGANALYZER_CRON_LOG_DIR"); v != "" {
+		cfg.CronLogDir = v
+	}
+	if v := os.Getenv("LOGANALYZER_DEDUP_STACK_FRAMES"); v != "" {
+		cfg.DedupStackFrames, _ = strconv.ParseBool(v)
+	}
+	if v := os.Getenv("LOGANALYZER_ELI5_SUFFIX"); v != "" {
+		cfg.ELI5Suffix = v
+	}
+	if v := os.Getenv("LOGANALYZER_DEFER_LARGE_UPLOADS"); v != "" {
+		cfg.DeferLargeUploads, _ = strconv.ParseBool(v)
+	}
+	if v := os.Getenv("LOGANALYZER_QUIET_HOURS"); v != "" {
+		cfg.QuietHours = v
+	}
+	if v := os.Getenv("LOGANALYZER_AUTO_DETECT_FORMAT"); v != "" {
+		cfg.AutoDetectFormat, _ = strconv.ParseBool(v)
+	}
+	if v := os.Getenv("LOGANALYZER_EMPHASIZE_RECENT"); v != "" {
+		cfg.EmphasizeRecent, _ = strconv.ParseBool(v)
+	}
+	if v := os.Getenv("LOGANALYZER_RECENT_ENTRY_LINES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.RecentEntryLines = n
+		}
+	}
+	if v := os.Getenv("LOGANALYZER_GUARD_PROMPT_INJECTION"); v != "" {
+		cfg.GuardPromptInjection, _ = strconv.ParseBool(v)
+	}
+	if v := os.Getenv("LOGANALYZER_DELIVERY_TIMEOUT"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.DeliveryTimeoutSec = n
+		}
+	}
+	if v := os.Getenv("LOGANALYZER_NOTIFY_ON_START"); v != "" {
+		cfg.NotifyOnStart, _ = strconv.ParseBool(v)
+	}
+	if v := os.Getenv("LOGANALYZER_HEARTBEAT_INTERVAL_SEC"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.HeartbeatIntervalSec = n
+		}
+	}
+	if v := os.Getenv("LOGANALYZER_STREAM_TO_CHAT"); v != "" {
+		cfg.StreamToChat, _ = strconv.ParseBool(v)
+	}
+	if v := os.Getenv("LOGANALYZER_STREAM_POST_INTERVAL_SEC"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.StreamPostIntervalSec = n
+		}
+	}
+	if v := os.Getenv("LOGANALYZER_STREAM_EVERY_LINES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.StreamEveryLines = n
+		}
+	}
+	if v := os.Getenv("LOGANALYZER_ANNOTATE_SOURCE_LOG"); v != "" {
+		cfg.AnnotateSourceLog, _ = strconv.ParseBool(v)
+	}
+	if v := os.Getenv("LOGANALYZER_HIGHLIGHT_DIFFS"); v != "" {
+		cfg.HighlightDiffs, _ = strconv.ParseBool(v)
+	}
+	if v := os.Getenv("LOGANALYZER_STRIP_ANSI"); v != "" {
+		cfg.StripANSI, _ = strconv.ParseBool(v)
+	}
+	if v := os.Getenv("LOGANALYZER_REDACT_SECRETS"); v != "" {
+		cfg.RedactSecrets, _ = strconv.ParseBool(v)
+	}
+	if v := os.Getenv("LOGANALYZER_REDACT_HOSTS"); v != "" {
+		cfg.RedactHostsInResult, _ = strconv.ParseBool(v)
+	}
+	if v := os.Getenv("LOGANALYZER_REDACT_HOST_PATTERN"); v != "" {
+		cfg.RedactHostPattern = v
+	}
+	if v := os.Getenv("LOGANALYZER_REDACT_DOMAIN_SUFFIX"); v != "" {
+		cfg.RedactDomainSuffix = v
 	}
 
-	// Initialize semaphore for concurrency control
-	p.semaphore = make(chan struct{}, p.config.MaxConcurrent)
+	if cfg.LogFormat != "text" && cfg.LogFormat != "json" {
+		p.logf("warn", "Unknown log format %q, using text", cfg.LogFormat)
+		cfg.LogFormat = "text"
+	}
+	if cfg.OutputFormat != "text" && cfg.OutputFormat != "json" {
+		p.logf("warn", "Unknown output format %q, using text", cfg.OutputFormat)
+		cfg.OutputFormat = "text"
+	}
+	switch cfg.WorkspaceIsolation {
+	case workspaceShared, workspaceTempDir, workspaceWorktree, workspaceCopy:
+	default:
+		p.logf("warn", "Unknown workspace isolation %q, using shared", cfg.WorkspaceIsolation)
+		cfg.WorkspaceIsolation = workspaceShared
+	}
+	switch cfg.ReplyMode {
+	case "truncate", "split", "file":
+	default:
+		p.logf("warn", "Unknown reply mode %q, using truncate", cfg.ReplyMode)
+		cfg.ReplyMode = "truncate"
+	}
+	cfg.ProxyURL = p.normalizeProxyURLs(cfg.ProxyURL)
+	// Clamp against the merged range, whichever layer set the default
+	if cfg.DefaultTemperature != nil {
+		t := cfg.clampTemperature(*cfg.DefaultTemperature)
+		cfg.DefaultTemperature = &t
+	}
+	switch cfg.ReplyStyle {
+	case replyStylePlain, replyStyleMarkdown:
+	default:
+		p.logf("warn", "Unknown reply style %q, using plain", cfg.ReplyStyle)
+		cfg.ReplyStyle = replyStylePlain
+	}
+	return cfg
+}
 
-	// Initialize HTTP client for proxy mode
-	p.httpClient = &http.Client{
-		Timeout: time.Duration(p.config.Timeout+30) * time.Second,
+// buildDerivedState fills in the state derived from cfg before it is published; previous
+// is the snapshot being replaced by /analyzereload (nil at startup), whose cache, slots
+// and client are carried over when their settings did not change
+func (p *LogAnalyzerPlugin) buildDerivedState(cfg, previous *Config) {
+	cfg.hostRedactor = nil
+	if cfg.RedactHostsInResult {
+		redactor, err := buildHostRedactor(cfg.RedactHostPattern, cfg.RedactDomainSuffix)
+		if err != nil {
+			p.logf("warn", "Invalid host redaction pattern, using default: %v", err)
+			redactor, _ = buildHostRedactor("", cfg.RedactDomainSuffix)
+		}
+		cfg.hostRedactor = redactor
+	}
+
+	cfg.secretRules = nil
+	if cfg.RedactSecrets {
+		rules, err := buildSecretRules(cfg.SecretPatterns)
+		if err != nil {
+			p.logf("warn", "Ignoring extra secret patterns: %v", err)
+		}
+		cfg.secretRules = rules
 	}
 
-	// Ensure shared data directory exists
-	if err := os.MkdirAll(p.config.SharedDataPath, 0755); err != nil {
-		bot.Log("warn", fmt.Sprintf("Failed to create shared data directory: %v", err))
+	cfg.quietHours = nil
+	if cfg.QuietHours != "" {
+		window, err := parseQuietHours(cfg.QuietHours)
+		if err != nil {
+			p.logf("warn", "Ignoring quiet hours: %v", err)
+		}
+		cfg.quietHours = window
+	}
+
+	switch {
+	case previous != nil && previous.CacheTTLMinutes == cfg.CacheTTLMinutes && previous.CacheMaxEntries == cfg.CacheMaxEntries:
+		cfg.cache = previous.cache
+	case cfg.CacheTTLMinutes > 0:
+		cfg.cache = newResultCache(cfg.CacheMaxEntries, time.Duration(cfg.CacheTTLMinutes)*time.Minute)
+	default:
+		cfg.cache = nil
 	}
 
-	bot.Log("info", fmt.Sprintf("Log analyzer plugin started in %s mode", p.config.Mode))
-	if p.config.Mode == "proxy" {
-		bot.Log("info", fmt.Sprintf("  proxy_url: %s", p.config.ProxyURL))
+	// Initialize semaphore for concurrency control
+	// Tasks holding or awaiting a slot keep the semaphore they started with
+	if previous == nil || previous.MaxConcurrent != cfg.MaxConcurrent {
+		cfg.semaphore = make(chan struct{}, cfg.MaxConcurrent)
+	} else {
+		cfg.semaphore = previous.semaphore
+	}
+	// Initialize HTTP client for proxy mode
+	cfg.proxyURLs = parseProxyURLs(cfg.ProxyURL)
+	if previous == nil || previous.Timeout != cfg.Timeout || previous.MaxConcurrent != cfg.MaxConcurrent ||
+		previous.ProxyIdleConnTimeoutSec != cfg.ProxyIdleConnTimeoutSec {
+		cfg.httpClient = &http.Client{
+			Timeout:   time.Duration(cfg.Timeout+30) * time.Second,
+			Transport: newProxyTransport(cfg.MaxConcurrent, cfg.ProxyIdleConnTimeoutSec),
+		}
 	} else {
-		bot.Log("info", fmt.Sprintf("  workspace: %s", p.config.WorkspacePath))
+		cfg.httpClient = previous.httpClient
 	}
-	bot.Log("info", fmt.Sprintf("  shared_data: %s", p.config.SharedDataPath))
+}
 
-	return nil
+// newProxyTransport returns the HTTP transport used for proxy requests
+// It keeps one idle connection per concurrent slot and reaps them after idleTimeoutSec
+func newProxyTransport(maxConns, idleTimeoutSec int) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if maxConns > 0 {
+		transport.MaxIdleConnsPerHost = maxConns
+	}
+	transport.IdleConnTimeout = time.Duration(idleTimeoutSec) * time.Second
+	return transport
 }
 
 // OnStop is called when the plugin stops
 func (p *LogAnalyzerPlugin) OnStop() error {
+	p.stopping.Store(true)
+	if p.done != nil {
+		close(p.done)
+	}
+	if p.cancelTasks != nil {
+		p.drainTasks()
+	}
+	p.stopMetricsServer()
+	if p.tasks != nil {
+		if err := p.saveTasks(); err != nil {
+			p.logf("warn", "Failed to save tasks: %v", err)
+		}
+	}
 	return nil
 }
 
@@ -190,176 +1053,795 @@ func (p *LogAnalyzerPlugin) OnCommand(ctx context.Context, bot *pluginsdk.BotCli
 	case "analyzestatus":
 		p.handleStatus(bot, args, msg)
 		return true
+	case "analyzecron":
+		p.handleCron(bot, args, msg)
+		return true
+	case "analyzerequeue":
+		p.handleRequeue(bot, args, msg)
+		return true
+	case "analyzewarm":
+		p.handleWarm(bot, args, msg)
+		return true
+	case "analyzetransfer":
+		p.handleTransfer(bot, args, msg)
+		return true
+	case "analyzeresult":
+		p.handleResult(bot, args, msg)
+		return true
+	case "analyzeget":
+		p.handleGet(bot, args, msg)
+		return true
+	case "analyzecancel":
+		p.handleCancel(bot, args, msg)
+		return true
+	case "analyzestats":
+		p.handleStats(bot, msg)
+		return true
+	case "analyzequeue":
+		p.handleQueue(bot, msg)
+		return true
+	case "analyzeconfig":
+		p.handleConfig(bot, msg)
+		return true
+	case "analyzereload":
+		p.handleReload(bot, msg)
+		return true
 	}
 	return false
 }
 
 // handleHelp shows plugin help information
 func (p *LogAnalyzerPlugin) handleHelp(bot *pluginsdk.BotClient, msg *pluginsdk.Message) {
-	modeInfo := fmt.Sprintf("Mode: %s", p.config.Mode)
-	if p.config.Mode == "proxy" {
-		modeInfo += fmt.Sprintf(" (%s)", p.config.ProxyURL)
+	modeInfo := p.msgf("help.mode", p.cfg().Mode)
+	if p.cfg().Mode == "proxy" {
+		modeInfo += fmt.Sprintf(" (%s)", p.cfg().ProxyURL)
 	}
 
 	bot.Reply(msg,
-		pluginsdk.Text("🔍 Log Analyzer Plugin\n"),
+		pluginsdk.Text(p.msgf("help.title")+"\n"),
 		pluginsdk.Text("━━━━━━━━━━━━━━━━━━━━\n"),
-		pluginsdk.Text("AI-powered log analysis using knot-cli\n"),
+		pluginsdk.Text(p.msgf("help.intro")+"\n"),
 		pluginsdk.Text(modeInfo+"\n\n"),
-		pluginsdk.Text("Available Commands:\n\n"),
-		pluginsdk.Text("📊 /analyze <log_content>\n"),
-		pluginsdk.Text("   Analyze the given log content using AI\n"),
-		pluginsdk.Text("   The log content should be the error log\n"),
-		pluginsdk.Text("   you want to analyze\n\n"),
-		pluginsdk.Text("📋 /analyzestatus [task_id]\n"),
-		pluginsdk.Text("   Check the status of an analysis task\n"),
-		pluginsdk.Text("   Without task_id, shows all your tasks\n\n"),
-		pluginsdk.Text("❓ /analyzehelp\n"),
-		pluginsdk.Text("   Show this help message\n\n"),
-		pluginsdk.Text("Example:\n"),
-		pluginsdk.Text("  /analyze [component] sendRequest request: ...\n"),
+		pluginsdk.Text(p.msgf("help.commands")),
 	)
 }
 
 // handleAnalyze handles the analyze command
 func (p *LogAnalyzerPlugin) handleAnalyze(ctx context.Context, bot *pluginsdk.BotClient, args []string, msg *pluginsdk.Message) {
-	if len(args) == 0 {
-		bot.Reply(msg,
-			pluginsdk.Text("❌ Please provide log content to analyze\n\n"),
-			pluginsdk.Text("Usage: /analyze <log_content>\n"),
-			pluginsdk.Text("Example: /analyze [component] sendRequest request: ..."),
-		)
+	if p.stopping.Load() {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.shutting_down")))
 		return
 	}
 
 	// Check configuration based on mode
-	if p.config.Mode == "direct" && p.config.WorkspacePath == "" {
-		bot.Reply(msg, pluginsdk.Text("❌ Plugin not properly configured: workspace path not set\nPlease set WORKSPACE_PATH environment variable"))
+	if p.cfg().Mode == "direct" && p.cfg().WorkspacePath == "" {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.no_workspace")))
+		return
+	}
+
+	if p.cfg().Mode == "proxy" && len(p.cfg().proxyURLs) == 0 {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.no_proxy")))
 		return
 	}
 
-	if p.config.Mode == "proxy" && p.config.ProxyURL == "" {
-		bot.Reply(msg, pluginsdk.Text("❌ Plugin not properly configured: proxy URL not set\nPlease set KNOT_PROXY_URL environment variable"))
+	if wait := p.backendCooldownRemaining(time.Now()); wait > 0 {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.cooldown", int(math.Ceil(wait.Seconds())))))
 		return
 	}
 
-	// Generate unique task ID
-	taskID := generateShortID()
+	if p.cfg().Mode == "proxy" {
+		if err := p.checkProxyHealth(); err != nil {
+			bot.Reply(msg, pluginsdk.Text(p.msgf("err.unavailable")))
+			return
+		}
+	}
+
+	opts, args, err := p.parseAnalyzeArgs(args)
+	if err != nil {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.generic", err)))
+		return
+	}
+	if opts.TicketID != "" && p.cfg().TicketWebhook == "" {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.no_ticket_webhook")))
+		return
+	}
+
+	// Prefer the raw message text, which keeps the line breaks and indentation lost in args
 	logContent := strings.Join(args, " ")
+	if raw, ok := rawLogContent(msg.Text, args); ok {
+		logContent = raw
+	}
 
-	// Create task status
-	task := &TaskStatus{
-		ID:        taskID,
-		Status:    "pending",
-		StartTime: time.Now(),
-		UserID:    msg.UserID,
-		GroupID:   msg.GroupID,
+	// A lone http(s) URL (e.g. a CI log link) is fetched and analyzed
+	source := ""
+	if len(args) == 1 && isLogURL(args[0]) {
+		content, err := p.fetchLogURL(ctx, args[0])
+		if err != nil {
+			bot.Reply(msg, pluginsdk.Text(p.msgf("err.fetch_url", args[0], err)))
+			return
+		}
+		logContent = content
+		source = args[0]
 	}
 
-	p.taskMutex.Lock()
-	p.tasks[taskID] = task
-	p.taskMutex.Unlock()
+	// Attached log files and incident bundles (archives of text files)
+	if att, ok := findFileAttachment(msg); ok {
+		switch {
+		case isArchiveName(att.Name):
+			content, files, err := p.loadArchiveAttachment(att)
+			if err != nil {
+				bot.Reply(msg, pluginsdk.Text(p.msgf("err.read_archive", att.Name, err)))
+				return
+			}
+			logContent = content
+			source = p.msgf("source.archive", att.Name, files)
+		case isLogFileName(att.Name):
+			content, err := p.loadLogAttachment(att)
+			if err != nil {
+				bot.Reply(msg, pluginsdk.Text(p.msgf("err.read_attachment", att.Name, err)))
+				return
+			}
+			logContent = content
+			source = att.Name
+		case strings.TrimSpace(logContent) == "":
+			bot.Reply(msg, pluginsdk.Text(p.msgf("err.unsupported_attachment", att.Name)))
+			return
+		}
+	}
+
+	// Replying "/analyze" to a message analyzes that message; inline content wins
+	replyIgnored := false
+	if replyID, ok := findReplyID(msg); ok {
+		if strings.TrimSpace(logContent) != "" {
+			replyIgnored = true
+		} else {
+			content, err := p.fetchRepliedText(replyID)
+			if err != nil {
+				bot.Reply(msg, pluginsdk.Text(p.msgf("err.generic", err)))
+				return
+			}
+			logContent = content
+			source = p.msgf("source.replied")
+		}
+	}
+
+	prepared, err := p.prepareLog(&opts, logContent)
+	var tooLarge *logTooLargeError
+	switch {
+	case errors.Is(err, errEmptyLog):
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.no_log")))
+		return
+	case errors.As(err, &tooLarge):
+		hint := p.msgf("hint.upload_or_trim")
+		if source != "" {
+			hint = p.msgf("hint.trim")
+		}
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.log_too_large", tooLarge.size, tooLarge.max)+"\n"+hint))
+		return
+	}
+	logContent, format := prepared.content, prepared.format
+
+	task, err := p.createUserTask(msg, opts)
+	if err != nil {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.generic", err)))
+		return
+	}
+	taskID := task.ID
+	task.InputTruncated = prepared.truncated
+	task.Source = source
+	task.LogFormat = format
 
 	// Acknowledge the request
-	bot.Reply(msg,
-		pluginsdk.Text(fmt.Sprintf("🔍 Analysis Task Created\n")),
-		pluginsdk.Text("━━━━━━━━━━━━━━━━━━━━\n"),
-		pluginsdk.Text(fmt.Sprintf("📋 Task ID: %s\n", taskID)),
-		pluginsdk.Text(fmt.Sprintf("📝 Log Length: %d chars\n", len(logContent))),
-		pluginsdk.Text(fmt.Sprintf("🔧 Mode: %s\n", p.config.Mode)),
-		pluginsdk.Text("⏳ Status: Queued for analysis...\n\n"),
-		pluginsdk.Text("Use /analyzestatus "+taskID+" to check progress"),
+	ackParts := append(p.replyHeader(p.msgf("ack.title")),
+		p.replyField(p.msgf("label.task_id", taskID)),
+		p.replyField(p.msgf("ack.log_length", len(logContent))),
+		p.replyField(p.msgf("ack.mode", p.cfg().Mode)),
 	)
+	if source != "" {
+		ackParts = append(ackParts, p.replyField(p.msgf("label.source", source)))
+	}
+	if format != "" {
+		line := p.msgf("ack.format", format)
+		if opts.Profile != "" {
+			line += p.msgf("ack.profile", opts.Profile)
+		}
+		ackParts = append(ackParts, p.replyField(line))
+	}
+	if opts.TicketID != "" {
+		ackParts = append(ackParts, p.replyField(p.msgf("label.ticket", opts.TicketID)))
+	}
+	if len(opts.Tags) > 0 {
+		ackParts = append(ackParts, p.replyField(p.msgf("label.tags", strings.Join(opts.Tags, ", "))))
+	}
+	if task.InputTruncated {
+		ackParts = append(ackParts, p.replyField(p.msgf("ack.truncated")))
+	}
+	if replyIgnored {
+		ackParts = append(ackParts, p.replyField(p.msgf("ack.reply_ignored")))
+	}
+	ackParts = append(ackParts,
+		p.replyField(p.msgf("ack.queued")),
+		pluginsdk.Text("\n"+p.msgf("ack.check", taskID)),
+	)
+	bot.Reply(msg, ackParts...)
 
 	// Run analysis in background
-	go p.runAnalysis(task, logContent, msg)
+	task.logContent = logContent
+	p.startAnalysis(task, logContent, msg)
+}
+
+// errEmptyLog is returned by prepareLog when nothing is left to analyze
+var errEmptyLog = errors.New("no log content to analyze")
+
+// logTooLargeError is returned by prepareLog when a log exceeds MaxLogBytes
+type logTooLargeError struct {
+	size, max int
+}
+
+func (e *logTooLargeError) Error() string {
+	return fmt.Sprintf("log too large: %d bytes (max %d)", e.size, e.max)
+}
+
+// preparedLog is a submission's log after normalization
+type preparedLog struct {
+	content   string
+	format    string // detected log format, empty when auto-detection is off
+	truncated bool
+}
+
+// prepareLog normalizes log content the same way for every submission path:
+// secrets are masked, MaxLogBytes is enforced and the prompt profile is auto-selected into opts
+func (p *LogAnalyzerPlugin) prepareLog(opts *AnalyzeOptions, logContent string) (preparedLog, error) {
+	// Mask credentials before the log reaches knot-cli, the proxy or disk
+	logContent = p.cfg().redactSecrets(logContent)
+	if strings.TrimSpace(logContent) == "" {
+		return preparedLog{}, errEmptyLog
+	}
+
+	// Count bytes, not characters: multibyte text costs the backend just as much
+	if p.cfg().MaxLogBytes > 0 && len(logContent) > p.cfg().MaxLogBytes {
+		return preparedLog{}, &logTooLargeError{size: len(logContent), max: p.cfg().MaxLogBytes}
+	}
+
+	return preparedLog{
+		content:   logContent,
+		format:    p.autoSelectProfile(opts, logContent),
+		truncated: looksTruncated(logContent),
+	}, nil
+}
+
+// loadArchiveAttachment reads an archive attachment into memory and combines its text files
+func (p *LogAnalyzerPlugin) loadArchiveAttachment(att fileAttachment) (string, int, error) {
+	data, err := p.readAttachment(att, p.cfg().ArchiveMaxBytes)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return extractArchive(data, att.Name, p.cfg().ArchiveMaxEntries, p.cfg().ArchiveMaxBytes)
+}
+
+// createTask registers a new pending task whose result is delivered to msg
+func (p *LogAnalyzerPlugin) createTask(msg *pluginsdk.Message, opts AnalyzeOptions) *TaskStatus {
+	p.taskMutex.Lock()
+	task := newTask(p.uniqueTaskIDLocked(msg), p.cfg(), msg, opts)
+	p.tasks[task.ID] = task
+	p.taskMutex.Unlock()
+	p.metrics.TaskCreated(task.Mode)
+	p.schedulePersist()
+
+	return task
+}
+
+// createUserTask registers a task for a user submission, enforcing MaxPerUser
+// and the uniqueness of an explicit --id
+func (p *LogAnalyzerPlugin) createUserTask(msg *pluginsdk.Message, opts AnalyzeOptions) (*TaskStatus, error) {
+	p.taskMutex.Lock()
+	if p.cfg().MaxPerUser > 0 {
+		if active := p.activeTasksLocked(msg.UserID); active >= p.cfg().MaxPerUser {
+			p.taskMutex.Unlock()
+			return nil, p.errf("err.max_per_user", active)
+		}
+	}
+	id := opts.ID
+	if id == "" {
+		id = p.uniqueTaskIDLocked(msg)
+	} else if _, exists := p.tasks[id]; exists {
+		p.taskMutex.Unlock()
+		return nil, p.errf("err.id_in_use", id)
+	}
+	task := newTask(id, p.cfg(), msg, opts)
+	p.tasks[task.ID] = task
+	p.taskMutex.Unlock()
+	p.metrics.TaskCreated(task.Mode)
+	p.schedulePersist()
+
+	return task, nil
+}
+
+// queuePositionsLocked returns the 1-based queue position of each task waiting for a
+// concurrency slot, in submission order; taskMutex must be held
+func (p *LogAnalyzerPlugin) queuePositionsLocked() map[string]int {
+	var waiting []*TaskStatus
+	for _, task := range p.tasks {
+		if task.waiting && task.Status == "pending" {
+			waiting = append(waiting, task)
+		}
+	}
+	sort.Slice(waiting, func(i, j int) bool {
+		return waiting[i].StartTime.Before(waiting[j].StartTime)
+	})
+	positions := make(map[string]int, len(waiting))
+	for i, task := range waiting {
+		positions[task.ID] = i + 1
+	}
+	return positions
+}
+
+// activeTasksLocked counts a user's pending and running tasks; taskMutex must be held
+func (p *LogAnalyzerPlugin) activeTasksLocked(userID int64) int {
+	active := 0
+	for _, task := range p.tasks {
+		if task.UserID == userID && (task.Status == "pending" || task.Status == "running") {
+			active++
+		}
+	}
+	return active
+}
+
+// newTask builds a pending task for a message
+func newTask(id string, cfg *Config, msg *pluginsdk.Message, opts AnalyzeOptions) *TaskStatus {
+	return &TaskStatus{
+		ID:        id,
+		Status:    "pending",
+		StartTime: time.Now(),
+		UserID:    msg.UserID,
+		GroupID:   msg.GroupID,
+		Mode:      cfg.Mode,
+		Options:   opts,
+		config:    cfg,
+		msg:       msg,
+	}
 }
 
 // runAnalysis executes the analysis based on mode
 func (p *LogAnalyzerPlugin) runAnalysis(task *TaskStatus, logContent string, msg *pluginsdk.Message) {
-	// Acquire semaphore for concurrency control
-	p.semaphore <- struct{}{}
-	defer func() { <-p.semaphore }()
+	prompt := p.buildPrompt(task, logContent)
+
+	if p.isDryRun(task) {
+		p.runDryRun(task, prompt, msg)
+		return
+	}
+
+	// Serve identical recent submissions from the cache without taking a slot
+	if task.config.cache != nil {
+		key := p.cacheKeyFor(task, prompt)
+		if entry, ok := task.config.cache.Get(key); ok {
+			if content, ok := p.cachedContent(task.config.cache, entry); ok {
+				p.taskLogf("info", task.ID, "Cache hit (result of task %s)", entry.taskID)
+				p.taskMutex.Lock()
+				task.Cached = true
+				p.taskMutex.Unlock()
+				p.completeTaskWithResult(task, entry.outputPath, content, 0, msg)
+				return
+			}
+		}
+		p.taskMutex.Lock()
+		task.cacheKey = key
+		p.taskMutex.Unlock()
+	}
 
-	// Update status to running
 	p.taskMutex.Lock()
+	task.waiting = true
+	p.taskMutex.Unlock()
+
+	// Acquire the group slot first so a group over its cap never holds a global slot
+	queued := false
+	groupSlot := p.groupSemaphore(task.GroupID, task.config.MaxConcurrentPerGroup)
+	if groupSlot != nil && !acquireSlot(groupSlot) {
+		queued = true
+	}
+
+	// Acquire semaphore for concurrency control from the task's own snapshot, so the
+	// slot is released into the same semaphore even if a reload replaced it
+	sem := task.config.semaphore
+	if !acquireSlot(sem) {
+		queued = true
+	}
+	var releaseOnce sync.Once
+	release := func() {
+		releaseOnce.Do(func() {
+			<-sem
+			if groupSlot != nil {
+				<-groupSlot
+			}
+		})
+	}
+	defer release()
+
+	p.taskMutex.Lock()
+	task.release = release
+	task.queued = queued
+	task.waiting = false
+	task.SlotAcquiredTime = time.Now()
+	p.taskMutex.Unlock()
+
+	// Tasks that waited for a slot past the shutdown grace period never start
+	if p.tasksCtx.Err() != nil {
+		p.finishTask(task, errTaskCancelled)
+		return
+	}
+
+	stopHeartbeat := p.startHeartbeat(task, msg)
+	defer stopHeartbeat()
+
+	// Proxy tasks start running once the proxy accepts them; a task keeps the mode it
+	// was created in across /analyzereload
+	if task.Mode == "proxy" {
+		p.runAnalysisViaProxy(task, prompt, msg)
+		return
+	}
+	if !p.startRunning(task, msg) {
+		return
+	}
+	p.runAnalysisDirect(task, prompt, msg)
+}
+
+// startRunning moves a task to running, unless it was cancelled while queued
+func (p *LogAnalyzerPlugin) startRunning(task *TaskStatus, msg *pluginsdk.Message) bool {
+	p.taskMutex.Lock()
+	if isFinished(task.Status) {
+		p.taskMutex.Unlock()
+		return false
+	}
 	task.Status = "running"
+	task.RunStartTime = time.Now()
+	queued := task.queued
 	p.taskMutex.Unlock()
+	p.schedulePersist()
+
+	// Tell the user when a task that had to wait in the queue finally starts
+	if task.config.NotifyOnStart && queued && !task.silent {
+		p.bot.Reply(msg, pluginsdk.Text(p.msgf("progress.started", task.ID)))
+	}
+	return true
+}
+
+// temperatureFor returns the model temperature for a task, or nil to use the backend default
+func (p *LogAnalyzerPlugin) temperatureFor(task *TaskStatus) *float64 {
+	if task.Options.Temperature != nil {
+		return task.Options.Temperature
+	}
+	return task.config.DefaultTemperature
+}
+
+// modelFor returns the AI model for a task, or "" to use the backend default
+func (p *LogAnalyzerPlugin) modelFor(task *TaskStatus) string {
+	if task.Options.Model != "" {
+		return task.Options.Model
+	}
+	return task.config.Model
+}
+
+// clampTemperature limits a temperature to the configured range
+func (c *Config) clampTemperature(t float64) float64 {
+	return math.Max(c.MinTemperature, math.Min(c.MaxTemperature, t))
+}
+
+// timeoutFor returns the analysis timeout in seconds for a mode
+func (c *Config) timeoutFor(mode string) int {
+	switch {
+	case mode == "direct" && c.TimeoutDirect > 0:
+		return c.TimeoutDirect
+	case mode == "proxy" && c.TimeoutProxy > 0:
+		return c.TimeoutProxy
+	}
+	return c.Timeout
+}
+
+// taskTimeout returns the timeout in seconds for a task, honoring its --timeout override
+// The ceiling follows the mode the task runs in, from the snapshot it was created with
+func (p *LogAnalyzerPlugin) taskTimeout(task *TaskStatus) int {
+	if task.Options.TimeoutSec > 0 {
+		return task.Options.TimeoutSec
+	}
+	return task.config.timeoutFor(task.Mode)
+}
+
+// acquireSlot takes a slot from sem, blocking if none is free
+// It returns true if a slot was available immediately
+func acquireSlot(sem chan struct{}) bool {
+	select {
+	case sem <- struct{}{}:
+		return true
+	default:
+		sem <- struct{}{}
+		return false
+	}
+}
+
+// globalSemaphore returns the current global concurrency slots
+func (p *LogAnalyzerPlugin) globalSemaphore() chan struct{} {
+	return p.cfg().semaphore
+}
+
+// cfg returns the current configuration snapshot; it must not be modified
+func (p *LogAnalyzerPlugin) cfg() *Config {
+	return p.config.Load()
+}
+
+// groupSemaphore returns the concurrency slots for a group capped at limit, or nil when
+// groups are uncapped
+// Private chats (group ID 0) are only bound by the global limit
+func (p *LogAnalyzerPlugin) groupSemaphore(groupID int64, limit int) chan struct{} {
+	if limit <= 0 || groupID == 0 {
+		return nil
+	}
+
+	p.groupSlotsMutex.Lock()
+	defer p.groupSlotsMutex.Unlock()
+
+	slots, ok := p.groupSlots[groupID]
+	if !ok {
+		slots = make(chan struct{}, limit)
+		p.groupSlots[groupID] = slots
+	}
+	return slots
+}
+
+// resetGroupSlots drops the per-group slots after the group cap changed; tasks holding
+// or awaiting a slot keep the semaphore they started with
+func (p *LogAnalyzerPlugin) resetGroupSlots() {
+	p.groupSlotsMutex.Lock()
+	p.groupSlots = make(map[int64]chan struct{})
+	p.groupSlotsMutex.Unlock()
+}
+
+// busyGroupSlots returns the slots held per group, for groups holding at least one
+func (p *LogAnalyzerPlugin) busyGroupSlots() map[int64]int {
+	p.groupSlotsMutex.Lock()
+	defer p.groupSlotsMutex.Unlock()
+
+	busy := make(map[int64]int)
+	for groupID, slots := range p.groupSlots {
+		if n := len(slots); n > 0 {
+			busy[groupID] = n
+		}
+	}
+	return busy
+}
+
+// buildProxyRequest returns the /analyze request body for a proxy-mode analysis
+func (p *LogAnalyzerPlugin) buildProxyRequest(task *TaskStatus, logContent string) ProxyAnalyzeRequest {
+	reqBody := ProxyAnalyzeRequest{
+		RequestID:   task.ID,
+		LogContent:  logContent,
+		Temperature: p.temperatureFor(task),
+		Model:       p.modelFor(task),
+	}
+	if task.config.OutputFormat == "json" {
+		reqBody.OutputFormat = "json"
+	}
+	reqBody.Profile = task.Options.Profile
+	reqBody.Instruction = task.Options.Instruction
+	return reqBody
+}
+
+// systemPromptFor returns the system prompt file for a task, honoring its --profile
+func (p *LogAnalyzerPlugin) systemPromptFor(task *TaskStatus) string {
+	if path, ok := task.config.PromptProfiles[task.Options.Profile]; ok && task.Options.Profile != "" {
+		return path
+	}
+	return task.config.SystemPromptPath
+}
+
+// useCodebase reports whether a direct-mode task lets knot-cli scan the workspace
+func (p *LogAnalyzerPlugin) useCodebase(task *TaskStatus) bool {
+	return task.config.UseCodebase && !task.Options.NoCodebase
+}
+
+// buildCLIArgs returns the knot-cli arguments for a direct-mode analysis in workspace
+func (p *LogAnalyzerPlugin) buildCLIArgs(task *TaskStatus, workspace, logContent string) []string {
+	cmdArgs := []string{"chat"}
+
+	if workspace != "" {
+		cmdArgs = append(cmdArgs, "-w", workspace)
+	}
+
+	if path := p.systemPromptFor(task); path != "" {
+		cmdArgs = append(cmdArgs, "--system-prompt", path)
+	}
+
+	if model := p.modelFor(task); model != "" {
+		cmdArgs = append(cmdArgs, "--model", model)
+	}
+
+	if temp := p.temperatureFor(task); temp != nil {
+		cmdArgs = append(cmdArgs, "--temperature", strconv.FormatFloat(*temp, 'f', -1, 64))
+	}
+
+	if task.config.OutputFormat == "json" {
+		cmdArgs = append(cmdArgs, jsonOutputArgs...)
+	}
 
-	if p.config.Mode == "proxy" {
-		p.runAnalysisViaProxy(task, logContent, msg)
-	} else {
-		p.runAnalysisDirect(task, logContent, msg)
+	cmdArgs = append(cmdArgs, task.config.ExtraCLIArgs...)
+
+	cmdArgs = append(cmdArgs, "-p", logContent)
+	if p.useCodebase(task) {
+		cmdArgs = append(cmdArgs, "--codebase")
 	}
+	return cmdArgs
 }
 
 // runAnalysisViaProxy calls the knot-proxy HTTP service
 func (p *LogAnalyzerPlugin) runAnalysisViaProxy(task *TaskStatus, logContent string, msg *pluginsdk.Message) {
 	// Prepare request
-	reqBody := ProxyAnalyzeRequest{
-		RequestID:  task.ID,
-		LogContent: logContent,
-	}
-
-	jsonBody, err := json.Marshal(reqBody)
+	jsonBody, err := json.Marshal(p.buildProxyRequest(task, logContent))
 	if err != nil {
 		p.completeTask(task, "", fmt.Errorf("failed to marshal request: %v", err), msg)
 		return
 	}
 
-	// Send analyze request
-	analyzeURL := p.config.ProxyURL + "/analyze"
-	p.bot.Log("info", fmt.Sprintf("[%s] Sending analyze request to proxy: %s", task.ID, analyzeURL))
+	// Stop retrying or polling once the task is cancelled or the plugin stops
+	ctx, cancel := context.WithCancel(p.tasksCtx)
+	defer cancel()
+	p.taskMutex.Lock()
+	task.cancel = cancel
+	cancelled := isFinished(task.Status)
+	p.taskMutex.Unlock()
+	if cancelled {
+		return
+	}
 
-	resp, err := p.httpClient.Post(analyzeURL, "application/json", bytes.NewBuffer(jsonBody))
+	// Send analyze request; the task stays pending until a proxy instance accepts it
+	var resp *http.Response
+	proxyURL := ""
+	err = errors.New("proxy URL not configured")
+	for _, base := range p.proxyOrder() {
+		analyzeURL := proxyEndpoint(base, "analyze")
+		p.taskLogf("info", task.ID, "Sending analyze request to proxy: %s", analyzeURL)
+
+		resp, err = p.postAnalyzeRequest(ctx, task.ID, analyzeURL, jsonBody)
+		if err == nil {
+			proxyURL = base
+			break
+		}
+		// Only unreachable instances fail over; a rejection would repeat elsewhere
+		if ctx.Err() != nil || errorCategory(err) != errorCategoryConnection {
+			break
+		}
+		p.taskLogf("warn", task.ID, "Proxy %s unreachable, trying next instance", base)
+	}
+	if ctx.Err() != nil {
+		p.finishTask(task, errTaskCancelled)
+		return
+	}
 	if err != nil {
-		p.completeTask(task, "", fmt.Errorf("failed to connect to proxy: %v", err), msg)
+		p.completeTask(task, "", err, msg)
+		return
+	}
+
+	// Poll and cancel on the instance that accepted the task
+	p.taskMutex.Lock()
+	task.proxyURL = proxyURL
+	p.taskMutex.Unlock()
+
+	// Only poll once the proxy confirms it accepted the job
+	if err := checkAnalyzeResponse(resp); err != nil {
+		p.completeTask(task, "", err, msg)
+		return
+	}
+
+	if !p.startRunning(task, msg) {
 		return
 	}
-	resp.Body.Close()
 
-	// Poll for status
-	statusURL := fmt.Sprintf("%s/status/%s", p.config.ProxyURL, task.ID)
-	pollInterval := 2 * time.Second
-	timeout := time.After(time.Duration(p.config.Timeout) * time.Second)
+	// Poll for status until done, timed out or cancelled
+
+	statusURL := proxyEndpoint(proxyURL, "status", task.ID)
+	pollInterval := time.Duration(task.config.PollIntervalMs) * time.Millisecond
+	if pollInterval <= 0 {
+		pollInterval = 500 * time.Millisecond
+	}
+	maxPollInterval := time.Duration(task.config.MaxPollIntervalMs) * time.Millisecond
+	if maxPollInterval < pollInterval {
+		maxPollInterval = pollInterval
+	}
+	timeoutSec := p.taskTimeout(task)
+	timeout := time.After(time.Duration(timeoutSec) * time.Second)
+
+	// Consecutive failed polls; the interval keeps backing off while the proxy is unreachable
+	failures := 0
+	sizeMismatches := 0
+	pollFailed := func(err error) bool {
+		failures++
+		p.taskLogf("warn", task.ID, "Status poll %d failed: %v", failures, err)
+		if task.config.MaxPollFailures > 0 && failures >= task.config.MaxPollFailures {
+			p.completeTask(task, "", withCategory(errorCategoryConnection,
+				fmt.Errorf("lost contact with proxy after %d failed status checks: %v", failures, err)), msg)
+			return true
+		}
+		return false
+	}
 
 	for {
 		select {
+		case <-ctx.Done():
+			// Already finished by /analyzecancel, otherwise the plugin is stopping
+			if p.finishTask(task, errTaskCancelled) {
+				p.cancelProxyTask(proxyURL, task.ID)
+			}
+			return
 		case <-timeout:
-			p.completeTask(task, "", fmt.Errorf("analysis timed out after %d seconds", p.config.Timeout), msg)
+			p.completeTask(task, "", fmt.Errorf("%w after %d seconds", errAnalysisTimeout, timeoutSec), msg)
 			return
 		case <-time.After(pollInterval):
+			// Back off so short jobs return fast and long ones poll less often
+			pollInterval = min(pollInterval*2, maxPollInterval)
+
 			// Check status
-			statusResp, err := p.httpClient.Get(statusURL)
+			statusReq, err := p.newProxyRequest(ctx, http.MethodGet, statusURL, nil)
 			if err != nil {
-				p.bot.Log("warn", fmt.Sprintf("[%s] Failed to get status: %v", task.ID, err))
+				p.completeTask(task, "", fmt.Errorf("failed to build status request: %v", err), msg)
+				return
+			}
+			statusResp, err := p.doProxyRequest(statusReq)
+			if err != nil {
+				if ctx.Err() == nil && pollFailed(fmt.Errorf("failed to get status: %v", err)) {
+					return
+				}
 				continue
 			}
 
 			var status ProxyStatusResponse
 			if err := json.NewDecoder(statusResp.Body).Decode(&status); err != nil {
 				statusResp.Body.Close()
-				p.bot.Log("warn", fmt.Sprintf("[%s] Failed to decode status: %v", task.ID, err))
+				if pollFailed(fmt.Errorf("failed to decode status: %v", err)) {
+					return
+				}
 				continue
 			}
 			statusResp.Body.Close()
+			failures = 0
 
-			p.bot.Log("info", fmt.Sprintf("[%s] Status: %s", task.ID, status.Status))
+			p.taskLogf("info", task.ID, "Status: %s", status.Status)
 
 			if status.Status == "completed" {
+				// A short or padded body means a partial response; fetch the status again
+				if !contentSizeMatches(status) {
+					sizeMismatches++
+					p.taskLogf("warn", task.ID, "Proxy content is %d bytes, expected %d (attempt %d/%d)",
+						len(status.Content), status.ContentSize, sizeMismatches, proxyContentAttempts)
+					if sizeMismatches < proxyContentAttempts {
+						continue
+					}
+					p.completeTask(task, "", withCategory(errorCategoryBackend,
+						fmt.Errorf("proxy returned incomplete content (%d of %d bytes)", len(status.Content), status.ContentSize)), msg)
+					return
+				}
+
 				// Save content to local shared data
-				outputPath := filepath.Join(p.config.SharedDataPath, fmt.Sprintf("analysis_%s.txt", task.ID))
+				outputPath, err := p.outputPathFor(task)
+				if err != nil {
+					p.completeTask(task, "", err, msg)
+					return
+				}
+				status.Content = task.config.cleanOutput(status.Content)
 				if status.Content != "" {
 					if err := os.WriteFile(outputPath, []byte(status.Content), 0644); err != nil {
-						p.bot.Log("warn", fmt.Sprintf("[%s] Failed to save output: %v", task.ID, err))
+						p.taskLogf("warn", task.ID, "Failed to save output: %v", err)
 					}
 				}
+				if level := normalizeSeverity(status.Severity); level != "" {
+					p.taskMutex.Lock()
+					task.Severity = level
+					p.taskMutex.Unlock()
+				}
 				p.completeTaskWithResult(task, outputPath, status.Content, status.Duration, msg)
 				return
 			}
 
 			if status.Status == "failed" {
-				p.completeTask(task, "", fmt.Errorf("proxy error: %s", status.Error), msg)
+				p.completeTask(task, "", withCategory(errorCategoryBackend, fmt.Errorf("proxy error: %s", status.Error)), msg)
 				return
 			}
 
@@ -368,31 +1850,60 @@ func (p *LogAnalyzerPlugin) runAnalysisViaProxy(task *TaskStatus, logContent str
 	}
 }
 
+// proxyContentAttempts is how many completed status responses with a content size
+// mismatch are fetched before the task fails
+const proxyContentAttempts = 3
+
+// contentSizeMatches reports whether a completed status carries all of its content
+// The proxy may count content_size in bytes or characters, so either is accepted
+func contentSizeMatches(status ProxyStatusResponse) bool {
+	if status.ContentSize <= 0 {
+		return true
+	}
+	return len(status.Content) == status.ContentSize || utf8.RuneCountInString(status.Content) == status.ContentSize
+}
+
 // runAnalysisDirect executes knot-cli directly
 func (p *LogAnalyzerPlugin) runAnalysisDirect(task *TaskStatus, logContent string, msg *pluginsdk.Message) {
 	// Create output file path
-	outputFileName := fmt.Sprintf("analysis_%s.txt", task.ID)
-	outputPath := filepath.Join(p.config.SharedDataPath, outputFileName)
-
-	// Build knot-cli command
-	cmdArgs := []string{"chat"}
-
-	if p.config.WorkspacePath != "" {
-		cmdArgs = append(cmdArgs, "-w", p.config.WorkspacePath)
+	outputPath, err := p.outputPathFor(task)
+	if err != nil {
+		p.completeTask(task, "", err, msg)
+		return
 	}
 
-	if p.config.SystemPromptPath != "" {
-		cmdArgs = append(cmdArgs, "--system-prompt", p.config.SystemPromptPath)
+	// Set up the task's own workspace when isolation is enabled
+	ws, err := p.prepareWorkspace(task)
+	if err != nil {
+		p.completeTask(task, "", withCategory(errorCategoryInternal, fmt.Errorf("failed to prepare workspace: %v", err)), msg)
+		return
 	}
+	defer ws.remove()
+
+	// Build knot-cli command
+	cmdArgs := p.buildCLIArgs(task, ws.path, logContent)
 
-	cmdArgs = append(cmdArgs, "-p", logContent, "--codebase")
+	// Reject malformed values before starting the process
+	if err := task.config.validateCLIArgs(cmdArgs, logContent); err != nil {
+		p.completeTask(task, "", withCode(errorCodeConfigInvalid, err), msg)
+		return
+	}
 
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(p.config.Timeout)*time.Second)
+	// Create context with timeout, cancellable through /analyzecancel and OnStop
+	timeoutSec := p.taskTimeout(task)
+	ctx, cancel := context.WithTimeout(p.tasksCtx, time.Duration(timeoutSec)*time.Second)
 	defer cancel()
+	p.taskMutex.Lock()
+	task.cancel = cancel
+	cancelled := isFinished(task.Status)
+	p.taskMutex.Unlock()
+	if cancelled {
+		return
+	}
 
 	// Execute knot-cli command
-	cmd := exec.CommandContext(ctx, p.config.KnotCLIPath, cmdArgs...)
+	cmd := exec.CommandContext(ctx, task.config.KnotCLIPath, cmdArgs...)
+	ws.apply(cmd)
 
 	// Create output file
 	outputFile, err := os.Create(outputPath)
@@ -419,214 +1930,638 @@ func (p *LogAnalyzerPlugin) runAnalysisDirect(task *TaskStatus, logContent strin
 	// Start command
 	if err := cmd.Start(); err != nil {
 		outputFile.Close()
-		p.completeTask(task, outputPath, fmt.Errorf("failed to start knot-cli: %v", err), msg)
+		p.completeTask(task, outputPath, withCode(errorCodeCLIStartFailed, fmt.Errorf("failed to start knot-cli: %v", err)), msg)
 		return
 	}
 
-	// Collect output
+	// Collect output; both readers write to the builder and file, so writes are serialized
+	// Past MaxOutputBytes the file gets a marker and knot-cli is stopped
+	var outputMu sync.Mutex
 	var outputBuilder strings.Builder
+	var written int
+	outputCapped := false
+	writeOutput := func(line string) {
+		outputMu.Lock()
+		defer outputMu.Unlock()
+		if outputCapped {
+			return
+		}
+		if task.config.MaxOutputBytes > 0 && written+len(line)+1 > task.config.MaxOutputBytes {
+			outputCapped = true
+			marker := fmt.Sprintf("\n[Output truncated: exceeded %d bytes]\n", task.config.MaxOutputBytes)
+			outputBuilder.WriteString(marker)
+			outputFile.WriteString(marker)
+			cancel()
+			return
+		}
+		written += len(line) + 1
+		outputBuilder.WriteString(line + "\n")
+		outputFile.WriteString(line + "\n")
+	}
+	streamer := p.newChatStreamer(task, msg)
+	var readers sync.WaitGroup
+	readers.Add(2)
+
+	// A reader that fails (e.g. a line over MaxLineBytes) records the error and keeps
+	// draining its pipe so knot-cli never blocks on a full pipe
+	var scanErr error
+	newScanner := func(r io.Reader) *bufio.Scanner {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), max(task.config.MaxLineBytes, bufio.MaxScanTokenSize))
+		return scanner
+	}
+	finishReading := func(scanner *bufio.Scanner, r io.Reader) {
+		if err := scanner.Err(); err != nil {
+			outputMu.Lock()
+			if scanErr == nil {
+				scanErr = err
+			}
+			outputMu.Unlock()
+			io.Copy(io.Discard, r)
+		}
+	}
 
 	// Read stdout
 	go func() {
-		scanner := bufio.NewScanner(stdout)
+		defer readers.Done()
+		scanner := newScanner(stdout)
 		for scanner.Scan() {
-			line := scanner.Text()
-			outputBuilder.WriteString(line + "\n")
-			outputFile.WriteString(line + "\n")
+			line := task.config.cleanOutput(scanner.Text())
+			writeOutput(line)
+			streamer.Append(line)
 		}
+		finishReading(scanner, stdout)
 	}()
 
-	// Read stderr
+	// Read stderr; the unfiltered tail is kept for failure messages
+	stderrTail := newLineRing(stderrTailLines)
 	go func() {
-		scanner := bufio.NewScanner(stderr)
+		defer readers.Done()
+		scanner := newScanner(stderr)
 		for scanner.Scan() {
-			line := scanner.Text()
+			line := task.config.cleanOutput(scanner.Text())
+			stderrTail.Add(line)
 			// Filter out progress messages, keep only important ones
 			if !strings.HasPrefix(line, "[") || strings.Contains(line, "错误") || strings.Contains(line, "Error") {
-				outputBuilder.WriteString(line + "\n")
-				outputFile.WriteString(line + "\n")
+				writeOutput(line)
 			}
 		}
+		finishReading(scanner, stderr)
 	}()
 
-	// Wait for command to complete
+	// Drain both pipes before waiting: Wait closes them, which would drop unread output
+	readers.Wait()
 	err = cmd.Wait()
 	outputFile.Close()
 
+	// The cap cancelled knot-cli; the task completes with what was captured
+	if outputCapped {
+		p.taskLogf("warn", task.ID, "Output exceeded %d bytes, knot-cli stopped", task.config.MaxOutputBytes)
+		p.completeTask(task, outputPath, nil, msg)
+		return
+	}
 	if ctx.Err() == context.DeadlineExceeded {
-		p.completeTask(task, outputPath, fmt.Errorf("analysis timed out after %d seconds", p.config.Timeout), msg)
+		p.completeTask(task, outputPath, fmt.Errorf("%w after %d seconds", errAnalysisTimeout, timeoutSec), msg)
+		return
+	}
+	if ctx.Err() == context.Canceled {
+		p.finishTask(task, errTaskCancelled)
+		return
+	}
+
+	if errors.Is(scanErr, bufio.ErrTooLong) {
+		err := fmt.Errorf("knot-cli output has a line longer than %d bytes", max(task.config.MaxLineBytes, bufio.MaxScanTokenSize))
+		p.completeTask(task, outputPath, withCode(errorCodeOutputTooLong, withCategory(errorCategoryInternal, err)), msg)
+		return
+	}
+	if scanErr != nil {
+		p.completeTask(task, outputPath, withCategory(errorCategoryInternal, fmt.Errorf("failed to read knot-cli output: %v", scanErr)), msg)
 		return
 	}
 
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		p.taskMutex.Lock()
+		task.ExitCode = exitErr.ExitCode()
+		p.taskMutex.Unlock()
+		if exitErr.ExitCode() < 0 {
+			err := errors.New(withStderrTail(fmt.Sprintf("knot-cli terminated: %v", err), stderrTail))
+			p.completeTask(task, outputPath, withCode(errorCodeCLITerminated, withCategory(errorCategoryBackend, err)), msg)
+			return
+		}
+		err := errors.New(withStderrTail(fmt.Sprintf("knot-cli exited with code %d", exitErr.ExitCode()), stderrTail))
+		p.completeTask(task, outputPath, withCode(errorCodeCLINonzeroExit, withCategory(errorCategoryBackend, err)), msg)
+		return
+	}
 	if err != nil {
-		p.completeTask(task, outputPath, fmt.Errorf("knot-cli error: %v", err), msg)
+		p.completeTask(task, outputPath, withCategory(errorCategoryInternal, fmt.Errorf("failed to execute knot-cli: %v", err)), msg)
 		return
 	}
 
 	p.completeTask(task, outputPath, nil, msg)
 }
 
-// completeTask finalizes the task and sends result to user
-func (p *LogAnalyzerPlugin) completeTask(task *TaskStatus, outputPath string, err error, msg *pluginsdk.Message) {
+// finishTask moves a task into its final state
+// It returns false if the task was already finished elsewhere (e.g. by the watchdog)
+func (p *LogAnalyzerPlugin) finishTask(task *TaskStatus, err error) bool {
+	p.taskMutex.Lock()
+	if isFinished(task.Status) {
+		p.taskMutex.Unlock()
+		return false
+	}
+
 	task.EndTime = time.Now()
 	task.Duration = task.EndTime.Sub(task.StartTime).Round(time.Millisecond).String()
-
-	if err != nil {
+	switch {
+	case errors.Is(err, errTaskCancelled):
+		task.Status = "cancelled"
+		task.ErrorCode = errorCodeCancelled
+	case err != nil:
 		task.Status = "failed"
 		task.Error = err.Error()
+		task.ErrorCategory = errorCategory(err)
+		task.ErrorCode = errorCode(err)
+	default:
+		task.Status = "completed"
+	}
+	p.updateBackendCooldownLocked(task)
+	p.tasks[task.ID] = task
+	p.metrics.TaskFinished(task.Mode, task.Status, errors.Is(err, errAnalysisTimeout), task.EndTime.Sub(task.StartTime))
+	p.schedulePersist()
+	status, elapsed := task.Status, task.EndTime.Sub(task.StartTime)
+	p.taskMutex.Unlock()
 
-		p.taskMutex.Lock()
-		p.tasks[task.ID] = task
-		p.taskMutex.Unlock()
+	if status == "failed" {
+		p.emitLog("warn", task.ID, elapsed, fmt.Sprintf("Task failed: %v", err))
+	} else {
+		p.emitLog("info", task.ID, elapsed, "Task "+status)
+	}
+	return true
+}
 
-		p.bot.Reply(msg,
-			pluginsdk.Text(fmt.Sprintf("❌ Analysis Failed\n")),
-			pluginsdk.Text("━━━━━━━━━━━━━━━━━━━━\n"),
-			pluginsdk.Text(fmt.Sprintf("📋 Task ID: %s\n", task.ID)),
-			pluginsdk.Text(fmt.Sprintf("⏱️  Duration: %s\n", task.Duration)),
-			pluginsdk.Text(fmt.Sprintf("❌ Error: %s", task.Error)),
-		)
+// updateBackendCooldownLocked starts the post-failure cooldown on backend and connection
+// failures and lifts it on any fresh success; taskMutex must be held
+func (p *LogAnalyzerPlugin) updateBackendCooldownLocked(task *TaskStatus) {
+	if task.config.PostFailureCooldownSec <= 0 {
 		return
 	}
+	switch {
+	case task.Status == "completed" && !task.Cached:
+		p.backendCooldownUntil = time.Time{}
+	case task.ErrorCategory == errorCategoryBackend || task.ErrorCategory == errorCategoryConnection:
+		p.backendCooldownUntil = task.EndTime.Add(time.Duration(task.config.PostFailureCooldownSec) * time.Second)
+	}
+}
+
+// backendCooldownRemaining returns how long new submissions are still rejected after a backend failure
+func (p *LogAnalyzerPlugin) backendCooldownRemaining(now time.Time) time.Duration {
+	p.taskMutex.RLock()
+	defer p.taskMutex.RUnlock()
+	if p.backendCooldownUntil.IsZero() {
+		return 0
+	}
+	return p.backendCooldownUntil.Sub(now)
+}
 
-	task.Status = "completed"
+// completeTask finalizes the task and sends result to user
+func (p *LogAnalyzerPlugin) completeTask(task *TaskStatus, outputPath string, err error, msg *pluginsdk.Message) {
+	if !p.finishTask(task, err) {
+		return
+	}
+	msg = p.deliveryTarget(task, msg)
 
-	p.taskMutex.Lock()
-	p.tasks[task.ID] = task
-	p.taskMutex.Unlock()
+	if err != nil {
+		p.notifyCompletion(task, outputPath, "")
+		if task.silent {
+			p.taskLogf("warn", task.ID, "Silent analysis failed: %v", err)
+			return
+		}
+		replyParts := []pluginsdk.MessageSegment{
+			pluginsdk.Text(p.msgf("result.failed") + "\n"),
+			pluginsdk.Text("━━━━━━━━━━━━━━━━━━━━\n"),
+			pluginsdk.Text(p.msgf("label.task_id", task.ID) + "\n"),
+			pluginsdk.Text(p.msgf("label.duration", task.Duration) + "\n"),
+		}
+		if task.ExitCode != 0 {
+			replyParts = append(replyParts, pluginsdk.Text(p.msgf("label.exit_code", task.ExitCode)+"\n"))
+		}
+		if task.ErrorCode != "" {
+			replyParts = append(replyParts, pluginsdk.Text(p.msgf("label.error_code", task.ErrorCode)+"\n"))
+		}
+		replyParts = append(replyParts, pluginsdk.Text(p.msgf("label.error", task.Error)))
+		p.bot.Reply(msg, replyParts...)
+		return
+	}
 
 	// Read analysis result
 	result, readErr := os.ReadFile(outputPath)
+	p.notifyCompletion(task, outputPath, string(result))
 	if readErr != nil {
+		if task.silent {
+			p.taskLogf("warn", task.ID, "Failed to read result: %v", readErr)
+			return
+		}
 		p.bot.Reply(msg,
-			pluginsdk.Text(fmt.Sprintf("⚠️ Analysis completed but failed to read result\n")),
-			pluginsdk.Text(fmt.Sprintf("📋 Task ID: %s\n", task.ID)),
-			pluginsdk.Text(fmt.Sprintf("📁 Output File: %s\n", outputPath)),
-			pluginsdk.Text(fmt.Sprintf("❌ Read Error: %s", readErr.Error())),
+			pluginsdk.Text(p.msgf("result.read_failed")+"\n"),
+			pluginsdk.Text(p.msgf("label.task_id", task.ID)+"\n"),
+			pluginsdk.Text(p.msgf("label.output_file", outputPath)+"\n"),
+			pluginsdk.Text(p.msgf("label.read_error", readErr.Error())),
 		)
 		return
 	}
 
-	p.sendResult(task, outputPath, string(result), msg)
+	p.cacheResult(task, outputPath, string(result))
+	if task.silent {
+		return
+	}
+	p.deliverResult(task, outputPath, string(result), msg)
 }
 
 // completeTaskWithResult finalizes the task with known result content
 func (p *LogAnalyzerPlugin) completeTaskWithResult(task *TaskStatus, outputPath, content string, durationSec float64, msg *pluginsdk.Message) {
-	task.EndTime = time.Now()
+	if !p.finishTask(task, nil) {
+		return
+	}
+	msg = p.deliveryTarget(task, msg)
 	if durationSec > 0 {
+		p.taskMutex.Lock()
 		task.Duration = fmt.Sprintf("%.2fs", durationSec)
-	} else {
-		task.Duration = task.EndTime.Sub(task.StartTime).Round(time.Millisecond).String()
+		p.taskMutex.Unlock()
 	}
-	task.Status = "completed"
+	p.notifyCompletion(task, outputPath, content)
 
-	p.taskMutex.Lock()
-	p.tasks[task.ID] = task
-	p.taskMutex.Unlock()
+	p.cacheResult(task, outputPath, content)
+	if task.silent {
+		return
+	}
+	p.deliverResult(task, outputPath, content, msg)
+}
+
+// deliverResult sends the result within DeliveryTimeoutSec so a slow chat backend
+// cannot strand the task; undelivered results can be fetched with /analyzeresult
+func (p *LogAnalyzerPlugin) deliverResult(task *TaskStatus, outputPath, content string, msg *pluginsdk.Message) {
+	if task.config.DeliveryTimeoutSec <= 0 {
+		p.sendResult(task, outputPath, content, msg)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(task.config.DeliveryTimeoutSec)*time.Second)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.sendResult(task, outputPath, content, msg)
+	}()
 
-	p.sendResult(task, outputPath, content, msg)
+	select {
+	case <-done:
+	case <-ctx.Done():
+		p.taskMutex.Lock()
+		task.Undelivered = true
+		p.taskMutex.Unlock()
+		p.taskLogf("warn", task.ID, "Result delivery exceeded %ds, giving up", task.config.DeliveryTimeoutSec)
+	}
 }
 
 // sendResult sends the analysis result to user
 func (p *LogAnalyzerPlugin) sendResult(task *TaskStatus, outputPath, resultStr string, msg *pluginsdk.Message) {
+	resultStr = task.config.cleanOutput(resultStr)
+
+	// Structured results carry their own fields; free text is parsed
+	var structured *structuredResult
+	if task.config.OutputFormat == "json" {
+		structured, _ = parseStructuredResult(resultStr)
+	}
+
 	// Extract requestID if present
 	requestID := extractRequestID(resultStr)
+	if structured != nil && structured.RequestID != "" {
+		requestID = structured.RequestID
+	}
+
+	// Prefer a structured severity from the backend, fall back to parsing the result
+	p.taskMutex.Lock()
+	if task.Severity == "" && structured != nil {
+		task.Severity = normalizeSeverity(structured.Severity)
+	}
+	if task.Severity == "" {
+		task.Severity = parseSeverity(resultStr)
+	}
+	severity := task.Severity
+	p.taskMutex.Unlock()
+
+	// Keep internal hosts out of shared channels, including the uploaded file
+	uploadPath := outputPath
+	if redacted := task.config.redactHosts(resultStr); redacted != resultStr {
+		resultStr = redacted
+		if outputPath != "" {
+			uploadPath = strings.TrimSuffix(outputPath, ".txt") + "_redacted.txt"
+			if err := os.WriteFile(uploadPath, []byte(resultStr), 0644); err != nil {
+				p.taskLogf("warn", task.ID, "Failed to write redacted output: %v", err)
+				uploadPath = ""
+			}
+		}
+	}
+	p.taskMutex.Lock()
+	task.resultPath = uploadPath
+	p.taskMutex.Unlock()
 
-	// Truncate result if too long for chat message
-	const maxLength = 3000
+	// Fit the result into chat messages according to ReplyMode
+	maxLength := task.config.MaxReplyChars
 	truncated := false
 	displayResult := resultStr
-	if len(displayResult) > maxLength {
-		displayResult = displayResult[:maxLength] + "\n\n... [Result truncated, see full output in file]"
+	if structured != nil {
+		displayResult = task.config.redactHosts(structured.render(p.msgf))
+	}
+	if task.config.HighlightDiffs {
+		displayResult = highlightDiffs(displayResult)
+	}
+	if p.markdownReplies() {
+		displayResult = fenceLogSections(displayResult)
+	}
+	var extraParts []string
+	switch {
+	case task.config.ReplyMode == "file" && uploadPath != "":
+		displayResult = p.msgf("result.as_file")
+		truncated = true
+	case maxLength <= 0 || len(displayResult) <= maxLength:
+	case task.config.ReplyMode == "split":
+		chunks := splitMessage(displayResult, maxLength)
+		reopen := false
+		for i := range chunks {
+			// Keep each Markdown chunk's fences balanced across the split
+			if p.markdownReplies() {
+				if reopen {
+					chunks[i] = "```\n" + chunks[i]
+				}
+				closed := closeOpenFence(chunks[i])
+				reopen = closed != chunks[i]
+				chunks[i] = closed
+			}
+			chunks[i] = p.msgf("result.part", i+1, len(chunks)) + "\n" + chunks[i]
+		}
+		displayResult, extraParts = chunks[0], chunks[1:]
+	default:
+		total := utf8.RuneCountInString(displayResult)
+		preview := truncateUTF8(displayResult, maxLength)
+		if p.markdownReplies() {
+			preview = closeOpenFence(preview)
+		}
+		displayResult = preview + "\n\n" + p.msgf("result.truncated", total)
 		truncated = true
 	}
 
+	// Files to upload alongside a truncated result
+	var uploads []deferredUpload
+	if truncated && uploadPath != "" {
+		uploads = append(uploads, deferredUpload{
+			path:    uploadPath,
+			name:    fmt.Sprintf("analysis_%s.txt", task.ID),
+			groupID: msg.GroupID,
+			userID:  msg.UserID,
+		})
+		if task.config.AnnotateSourceLog && task.logContent != "" {
+			annotatedPath := strings.TrimSuffix(outputPath, ".txt") + "_annotated_log.txt"
+			path, err := writeAnnotatedLog(annotatedPath, resultStr, task.logContent)
+			if err != nil {
+				p.taskLogf("warn", task.ID, "Failed to write annotated log: %v", err)
+			} else if path != "" {
+				uploads = append(uploads, deferredUpload{
+					path:    path,
+					name:    fmt.Sprintf("analysis_%s_log.txt", task.ID),
+					groupID: msg.GroupID,
+					userID:  msg.UserID,
+				})
+			}
+		}
+	}
+
+	// Large uploads outside quiet hours wait for the window
+	deferUpload := len(uploads) > 0 && task.config.shouldDeferUpload(time.Now())
+
 	// Send result
-	replyParts := []pluginsdk.MessageSegment{
-		pluginsdk.Text("✅ Analysis Completed\n"),
-		pluginsdk.Text("━━━━━━━━━━━━━━━━━━━━\n"),
-		pluginsdk.Text(fmt.Sprintf("📋 Task ID: %s\n", task.ID)),
-		pluginsdk.Text(fmt.Sprintf("⏱️  Duration: %s\n", task.Duration)),
+	var replyParts []pluginsdk.MessageSegment
+	severityLine := ""
+	if task.config.ShowSeverity && severity != "" {
+		severityLine = p.msgf("label.severity", getSeverityIcon(severity), severity)
+		if !p.markdownReplies() {
+			replyParts = append(replyParts, pluginsdk.Text(severityLine+"\n"))
+		}
+	}
+	title := p.msgf("result.completed")
+	if task.Cached {
+		title = p.msgf("result.completed_cached")
 	}
+	replyParts = append(replyParts, p.replyHeader(title)...)
+	if severityLine != "" && p.markdownReplies() {
+		replyParts = append(replyParts, p.replyField(severityLine))
+	}
+	replyParts = append(replyParts,
+		p.replyField(p.msgf("label.task_id", task.ID)),
+		p.replyField(p.msgf("label.duration", task.Duration)),
+	)
 
+	if task.Options.Question != "" {
+		replyParts = append(replyParts, p.replyField(p.msgf("label.question", task.Options.Question)))
+	}
+	if task.Options.Instruction != "" {
+		replyParts = append(replyParts, p.replyField(p.msgf("label.instruction", task.Options.Instruction)))
+	}
+	if task.InjectionSuspected {
+		replyParts = append(replyParts, p.replyField(p.msgf("result.injection")))
+	}
 	if requestID != "" {
-		replyParts = append(replyParts, pluginsdk.Text(fmt.Sprintf("🔑 Request ID: %s\n", requestID)))
+		replyParts = append(replyParts, p.replyField(p.msgf("label.request_id", requestID)))
+	}
+
+	if deferUpload {
+		replyParts = append(replyParts, p.replyField(p.msgf("result.deferred_upload", task.config.quietHours.startLabel(), task.ID)))
 	}
 
 	replyParts = append(replyParts,
-		pluginsdk.Text(fmt.Sprintf("📁 Output File: %s\n", outputPath)),
-		pluginsdk.Text("━━━━━━━━━━━━━━━━━━━━\n\n"),
+		p.replyField(p.msgf("label.output_file", outputPath)),
+		p.replyFooter(),
 		pluginsdk.Text(displayResult),
 	)
 
 	p.bot.Reply(msg, replyParts...)
+	for _, part := range extraParts {
+		if _, err := p.bot.Reply(msg, pluginsdk.Text(part)); err != nil {
+			p.taskLogf("warn", task.ID, "Failed to send result part: %v", err)
+		}
+	}
 
-	// If truncated, also upload the full file
-	if truncated && outputPath != "" {
-		if msg.GroupID > 0 {
-			p.bot.UploadGroupFile(msg.GroupID, outputPath, fmt.Sprintf("analysis_%s.txt", task.ID), "/")
-		} else {
-			p.bot.UploadPrivateFile(msg.UserID, outputPath, fmt.Sprintf("analysis_%s.txt", task.ID))
+	// Post to the referenced ticket without blocking delivery
+	if task.Options.TicketID != "" && task.config.TicketWebhook != "" {
+		go p.postTicketComment(task, resultStr)
+	}
+
+	// If truncated or in file mode, also upload the full file
+	if deferUpload {
+		p.uploads.add(task.ID, uploads...)
+	} else {
+		for _, upload := range uploads {
+			p.uploadResultFile(upload)
 		}
 	}
 }
 
 // handleStatus handles the analyzestatus command
+// Tasks are copied under taskMutex and the reply is sent after releasing it
 func (p *LogAnalyzerPlugin) handleStatus(bot *pluginsdk.BotClient, args []string, msg *pluginsdk.Message) {
-	p.taskMutex.RLock()
-	defer p.taskMutex.RUnlock()
+	// Admins can list every user's tasks; for others "all" is just their own
+	listAll := false
+	if len(args) > 0 && strings.EqualFold(args[0], "all") {
+		listAll = p.isAdmin(msg.UserID)
+		args = args[1:]
+	}
+
+	page := 1
+	if len(args) > 0 && strings.EqualFold(args[0], "page") {
+		n, err := 0, error(nil)
+		if len(args) == 2 {
+			n, err = strconv.Atoi(args[1])
+		}
+		if len(args) != 2 || err != nil || n < 1 {
+			bot.Reply(msg, pluginsdk.Text(p.msgf("usage.status_page")))
+			return
+		}
+		page, args = n, nil
+	}
 
 	if len(args) > 0 {
 		// Show specific task status
-		taskID := args[0]
-		task, exists := p.tasks[taskID]
+		taskID := strings.ToUpper(args[0])
+		p.taskMutex.RLock()
+		stored, exists := p.tasks[taskID]
+		var task TaskStatus
+		var positions map[string]int
+		if exists {
+			task, positions = *stored, p.queuePositionsLocked()
+		}
+		p.taskMutex.RUnlock()
 		if !exists {
-			bot.Reply(msg, pluginsdk.Text(fmt.Sprintf("❌ Task not found: %s", taskID)))
+			bot.Reply(msg, pluginsdk.Text(p.msgf("err.task_not_found", taskID)))
 			return
 		}
 
 		statusIcon := getStatusIcon(task.Status)
 		duration := ""
-		if task.Status == "completed" || task.Status == "failed" {
-			duration = fmt.Sprintf("\n⏱️  Duration: %s", task.Duration)
+		if isFinished(task.Status) {
+			duration = "\n" + p.msgf("label.duration", task.Duration)
 		} else {
-			duration = fmt.Sprintf("\n⏱️  Running: %s", time.Since(task.StartTime).Round(time.Second).String())
+			duration = "\n" + p.msgf("label.running", time.Since(task.StartTime).Round(time.Second).String())
 		}
 
-		errorMsg := ""
+		details := ""
+		if positions[task.ID] > 0 {
+			details = "\n" + p.msgf("label.queue", positions[task.ID], len(positions))
+		}
 		if task.Error != "" {
-			errorMsg = fmt.Sprintf("\n❌ Error: %s", task.Error)
+			details += "\n" + p.msgf("label.error", task.Error)
+		}
+		if task.ExitCode != 0 {
+			details += "\n" + p.msgf("label.exit_code", task.ExitCode)
+		}
+		if task.ErrorCode != "" {
+			details += "\n" + p.msgf("label.error_code", task.ErrorCode)
+		}
+		if len(task.Options.Tags) > 0 {
+			details += "\n" + p.msgf("label.tags", strings.Join(task.Options.Tags, ", "))
+		}
+		if task.Undelivered {
+			details += "\n" + p.msgf("label.undelivered", task.ID)
 		}
 
 		bot.Reply(msg,
-			pluginsdk.Text(fmt.Sprintf("📊 Task Status\n")),
+			pluginsdk.Text(p.msgf("tasks.title")+"\n"),
 			pluginsdk.Text("━━━━━━━━━━━━━━━━━━━━\n"),
-			pluginsdk.Text(fmt.Sprintf("📋 Task ID: %s\n", task.ID)),
-			pluginsdk.Text(fmt.Sprintf("%s Status: %s%s%s", statusIcon, task.Status, duration, errorMsg)),
+			pluginsdk.Text(p.msgf("label.task_id", task.ID)+"\n"),
+			pluginsdk.Text(p.msgf("label.status", statusIcon, p.statusLabel(task.Status))+duration+details),
 		)
 		return
 	}
 
 	// Show all user's tasks
-	var userTasks []*TaskStatus
+	var userTasks []TaskStatus
+	p.taskMutex.RLock()
 	for _, task := range p.tasks {
-		if task.UserID == msg.UserID {
-			userTasks = append(userTasks, task)
+		if listAll || task.UserID == msg.UserID {
+			userTasks = append(userTasks, *task)
 		}
 	}
+	positions := p.queuePositionsLocked()
+	p.taskMutex.RUnlock()
 
 	if len(userTasks) == 0 {
-		bot.Reply(msg, pluginsdk.Text("📊 You have no analysis tasks"))
+		bot.Reply(msg, pluginsdk.Text(p.msgf("tasks.none")))
+		return
+	}
+	// Most recent first, one page at a time
+	sort.Slice(userTasks, func(i, j int) bool {
+		return userTasks[i].StartTime.After(userTasks[j].StartTime)
+	})
+	pageSize := p.cfg().StatusPageSize
+	if pageSize <= 0 {
+		pageSize = len(userTasks)
+	}
+	pages := (len(userTasks) + pageSize - 1) / pageSize
+	if page > pages {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.no_such_page", page, pages)))
 		return
 	}
+	start := (page - 1) * pageSize
+	end := min(start+pageSize, len(userTasks))
+
+	title, pageCmd := p.msgf("tasks.mine"), "/analyzestatus page"
+	if listAll {
+		title, pageCmd = p.msgf("tasks.all"), "/analyzestatus all page"
+	}
 
-	response := "📊 Your Analysis Tasks\n━━━━━━━━━━━━━━━━━━━━\n"
-	for _, task := range userTasks {
+	var response strings.Builder
+	response.WriteString(title + "\n━━━━━━━━━━━━━━━━━━━━\n")
+	for _, task := range userTasks[start:end] {
 		statusIcon := getStatusIcon(task.Status)
-		response += fmt.Sprintf("%s %s: %s\n", statusIcon, task.ID, task.Status)
+		line := fmt.Sprintf("%s %s: %s", statusIcon, task.ID, p.statusLabel(task.Status))
+		if pos := positions[task.ID]; pos > 0 {
+			line += " (" + p.msgf("label.in_queue", pos, len(positions)) + ")"
+		}
+		if listAll {
+			line += " (" + p.msgf("label.user", task.UserID) + ")"
+		}
+		response.WriteString(line + "\n")
+	}
+	if pages > 1 {
+		response.WriteString("━━━━━━━━━━━━━━━━━━━━\n" + p.msgf("tasks.showing", start+1, end, len(userTasks)))
+		if page < pages {
+			response.WriteString(p.msgf("tasks.next_page", pageCmd, page+1))
+		}
+		response.WriteString("\n")
 	}
 
-	bot.Reply(msg, pluginsdk.Text(response))
+	// Long listings are split rather than failing to send
+	p.replyLong(bot, msg, response.String())
+}
+
+// validateCLIArgs rejects knot-cli arguments containing null bytes or control characters
+// The prompt may contain the control characters listed in PromptAllowedControlChars
+func (c *Config) validateCLIArgs(args []string, prompt string) error {
+	for _, arg := range args {
+		allowed := ""
+		if arg == prompt {
+			allowed = c.PromptAllowedControlChars
+		}
+		for _, r := range arg {
+			if r == 0 {
+				return fmt.Errorf("invalid knot-cli argument: contains null byte")
+			}
+			if unicode.IsControl(r) && !strings.ContainsRune(allowed, r) {
+				return fmt.Errorf("invalid knot-cli argument: contains control character %U", r)
+			}
+		}
+	}
+	return nil
 }
 
 // generateShortID generates a short unique ID
@@ -647,27 +2582,78 @@ func getStatusIcon(status string) string {
 		return "✅"
 	case "failed":
 		return "❌"
+	case "cancelled":
+		return "🛑"
 	default:
 		return "❓"
 	}
 }
 
+// requestIDPattern matches "requestId: x", "request_id=x", "\"requestId\": \"x\"" and "requestId - x"
+// The value runs up to whitespace, a quote, a comma or a closing brace
+var requestIDPattern = regexp.MustCompile(`(?i)\brequest[_\- ]?id["']?\s*(?:[:=]|-\s)\s*["']?([^\s"'},]+)`)
+
 // extractRequestID extracts requestID from analysis result
 func extractRequestID(result string) string {
-	// Look for requestID pattern in the result
-	lines := strings.Split(result, "\n")
-	for _, line := range lines {
-		if strings.Contains(strings.ToLower(line), "requestid") {
-			// Try to extract the ID value
-			parts := strings.Split(line, ":")
-			if len(parts) >= 2 {
-				return strings.TrimSpace(parts[len(parts)-1])
-			}
+	m := requestIDPattern.FindStringSubmatch(result)
+	if m == nil {
+		return ""
+	}
+	return strings.TrimRight(m[1], ".,;)")
+}
+
+// isAdmin reports whether the user may run admin-only commands
+func (p *LogAnalyzerPlugin) isAdmin(userID int64) bool {
+	for _, id := range p.cfg().AdminUserIDs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// parseIDList parses a comma-separated list of user IDs
+func parseIDList(s string) []int64 {
+	var ids []int64
+	for _, part := range strings.Split(s, ",") {
+		if id, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// parseList parses a comma-separated list, dropping empty entries
+func parseList(s string) []string {
+	var items []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			items = append(items, part)
+		}
+	}
+	return items
+}
+
+// parseGroupMap parses "groupID=value" pairs separated by commas
+// e.g. "123456=Chinese,789012=English"
+func parseGroupMap(s string) map[int64]string {
+	result := make(map[int64]string)
+	for _, pair := range strings.Split(s, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		groupID, err := strconv.ParseInt(strings.TrimSpace(parts[0]), 10, 64)
+		if err != nil {
+			continue
+		}
+		if value := strings.TrimSpace(parts[1]); value != "" {
+			result[groupID] = value
 		}
 	}
-	return ""
+	return result
 }
 
 func main() {
-	pluginsdk.Run(&LogAnalyzerPlugin{})
+	pluginsdk.Run(&LogAnalyzerPlugin{now: time.Now, newTicker: newTimeTicker})
 }
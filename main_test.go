@@ -0,0 +1,536 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unsafe"
+
+	pb "github.com/DaikonSushi/bot-platform/api/proto"
+	"github.com/DaikonSushi/bot-platform/pkg/pluginsdk"
+	"google.golang.org/grpc"
+)
+
+// fakeBot records what the plugin sends through the bot platform
+// Calls the tests don't expect hit the nil embedded client and panic
+type fakeBot struct {
+	pb.BotServiceClient
+
+	mu       sync.Mutex
+	messages []sentMessage
+	uploads  []sentFile
+	logs     []string
+
+	// sendGate, when set, holds SendMessage until it is closed (a slow chat backend)
+	sendGate chan struct{}
+}
+
+// sentMessage is one message sent through a fakeBot
+type sentMessage struct {
+	userID  int64
+	groupID int64
+	text    string
+}
+
+func (f *fakeBot) SendMessage(_ context.Context, in *pb.SendMessageRequest, _ ...grpc.CallOption) (*pb.SendMessageResponse, error) {
+	var sb strings.Builder
+	for _, seg := range in.Segments {
+		sb.WriteString(seg.Data["text"])
+	}
+	if f.sendGate != nil {
+		<-f.sendGate
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.messages = append(f.messages, sentMessage{userID: in.UserId, groupID: in.GroupId, text: sb.String()})
+	return &pb.SendMessageResponse{MessageId: int64(len(f.messages))}, nil
+}
+
+func (f *fakeBot) Log(_ context.Context, in *pb.LogRequest, _ ...grpc.CallOption) (*pb.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.logs = append(f.logs, in.Level+": "+in.Message)
+	return &pb.Empty{}, nil
+}
+
+// sentFile is one file uploaded through a fakeBot
+type sentFile struct {
+	userID  int64
+	groupID int64
+	path    string
+	name    string
+}
+
+func (f *fakeBot) UploadGroupFile(_ context.Context, in *pb.UploadGroupFileRequest, _ ...grpc.CallOption) (*pb.UploadFileResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.uploads = append(f.uploads, sentFile{groupID: in.GroupId, path: in.FilePath, name: in.FileName})
+	return &pb.UploadFileResponse{Success: true}, nil
+}
+
+func (f *fakeBot) UploadPrivateFile(_ context.Context, in *pb.UploadPrivateFileRequest, _ ...grpc.CallOption) (*pb.UploadFileResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.uploads = append(f.uploads, sentFile{userID: in.UserId, path: in.FilePath, name: in.FileName})
+	return &pb.UploadFileResponse{Success: true}, nil
+}
+
+// uploaded returns a copy of the files uploaded so far
+func (f *fakeBot) uploaded() []sentFile {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]sentFile(nil), f.uploads...)
+}
+
+// sent returns a copy of the messages sent so far
+func (f *fakeBot) sent() []sentMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]sentMessage(nil), f.messages...)
+}
+
+// newTestPlugin returns a plugin with the given configuration talking to a fake bot
+func newTestPlugin(cfg Config) (*LogAnalyzerPlugin, *fakeBot) {
+	fake := &fakeBot{}
+	// The SDK only builds clients around a live connection, so point its single
+	// unexported field at the fake
+	bot := &pluginsdk.BotClient{}
+	*(*pb.BotServiceClient)(unsafe.Pointer(bot)) = fake
+
+	p := &LogAnalyzerPlugin{
+		bot:        bot,
+		tasks:      make(map[string]*TaskStatus),
+		groupSlots: make(map[int64]chan struct{}),
+		done:       make(chan struct{}),
+		idGen:      newIDGenerator(cfg.TaskIDPrefix),
+		uploads:    newUploadQueue(),
+		tasksCtx:   context.Background(),
+	}
+	p.buildDerivedState(&cfg, nil)
+	p.config.Store(&cfg)
+	p.metrics = NewMetrics(func() int { return len(p.globalSemaphore()) })
+	return p, fake
+}
+
+func TestValidateCLIArgs(t *testing.T) {
+	const prompt = "line one\n\tline two\r\n"
+	tests := []struct {
+		name    string
+		allowed string
+		args    []string
+		wantErr bool
+	}{
+		{"clean", "\n\r\t", []string{"--workspace", "/srv/app", "-p", prompt}, false},
+		{"null byte in prompt", "\n\r\t", []string{"-p", "a\x00b"}, true},
+		{"newline in workspace", "\n\r\t", []string{"--workspace", "/srv/app\n--evil", "-p", prompt}, true},
+		{"null byte in workspace", "\n\r\t", []string{"--workspace", "/srv\x00app", "-p", prompt}, true},
+		{"escape in prompt", "\n\r\t", []string{"-p", "red \x1b[31m"}, true},
+		{"newline not allow-listed", "", []string{"-p", prompt}, true},
+		{"only tab allow-listed", "\t", []string{"-p", "a\tb"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.PromptAllowedControlChars = tt.allowed
+
+			promptArg := tt.args[len(tt.args)-1]
+			err := cfg.validateCLIArgs(tt.args, promptArg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateCLIArgs(%q) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunAnalysisDirectValidatesAgainstTaskConfig(t *testing.T) {
+	dir := t.TempDir()
+	cli := filepath.Join(dir, "knot-cli")
+	if err := os.WriteFile(cli, []byte("#!/bin/sh\necho done\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := DefaultConfig()
+	cfg.Mode = "direct"
+	cfg.KnotCLIPath = cli
+	cfg.SharedDataPath = dir
+	cfg.PromptAllowedControlChars = "\n"
+	p, _ := newTestPlugin(cfg)
+	task := &TaskStatus{config: p.cfg(), ID: "T1", Mode: "direct", Status: "running", StartTime: time.Now()}
+
+	// A reload that tightens the allow-list does not apply to the task already running
+	reloaded := *p.cfg()
+	reloaded.PromptAllowedControlChars = ""
+	p.config.Store(&reloaded)
+
+	p.runAnalysisDirect(task, "ERROR boom\nERROR again", &pluginsdk.Message{Type: "private", UserID: 1})
+	if task.Status != "completed" {
+		t.Errorf("task = %s %q, want completed under its own allow-list", task.Status, task.Error)
+	}
+}
+
+func TestGroupSemaphoreIsolatesGroups(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxConcurrent = 3
+	cfg.MaxConcurrentPerGroup = 1
+	p, _ := newTestPlugin(cfg)
+
+	// Saturate group 100
+	p.groupSemaphore(100, 1) <- struct{}{}
+
+	select {
+	case p.groupSemaphore(100, 1) <- struct{}{}:
+		t.Fatal("group 100 got a second slot over its cap")
+	default:
+	}
+	select {
+	case p.groupSemaphore(200, 1) <- struct{}{}:
+	default:
+		t.Fatal("group 200 was blocked by group 100")
+	}
+
+	if p.groupSemaphore(0, 1) != nil {
+		t.Error("private chats should only be bound by the global limit")
+	}
+}
+
+func TestTimeoutFor(t *testing.T) {
+	tests := []struct {
+		name          string
+		direct, proxy int
+		mode          string
+		want          int
+	}{
+		{"direct override", 60, 600, "direct", 60},
+		{"proxy override", 60, 600, "proxy", 600},
+		{"direct fallback", 0, 600, "direct", 300},
+		{"proxy fallback", 60, 0, "proxy", 300},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Timeout = 300
+			cfg.TimeoutDirect = tt.direct
+			cfg.TimeoutProxy = tt.proxy
+			if got := cfg.timeoutFor(tt.mode); got != tt.want {
+				t.Errorf("timeoutFor(%q) = %d, want %d", tt.mode, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunAnalysisDirectUsesDirectTimeout(t *testing.T) {
+	dir := t.TempDir()
+	cli := filepath.Join(dir, "knot-cli")
+	if err := os.WriteFile(cli, []byte("#!/bin/sh\nexec sleep 30\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Mode = "direct"
+	cfg.KnotCLIPath = cli
+	cfg.SharedDataPath = dir
+	cfg.Timeout = 300
+	cfg.TimeoutDirect = 1
+	p, _ := newTestPlugin(cfg)
+
+	task := &TaskStatus{config: p.cfg(), ID: "T1", Mode: "direct", Status: "running", StartTime: time.Now()}
+	p.runAnalysisDirect(task, "ERROR boom", &pluginsdk.Message{Type: "private", UserID: 1})
+
+	if task.Status != "failed" || !strings.Contains(task.Error, "timed out after 1 seconds") {
+		t.Errorf("task = %s %q, want a failure after the 1s direct timeout", task.Status, task.Error)
+	}
+}
+
+func TestTemperaturePropagation(t *testing.T) {
+	temp := 0.2
+
+	t.Run("direct", func(t *testing.T) {
+		dir := t.TempDir()
+		cli := filepath.Join(dir, "knot-cli")
+		argsFile := filepath.Join(dir, "args")
+		if err := os.WriteFile(cli, []byte("#!/bin/sh\necho \"$@\" > "+argsFile+"\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		cfg := DefaultConfig()
+		cfg.Mode = "direct"
+		cfg.KnotCLIPath = cli
+		cfg.SharedDataPath = dir
+		p, _ := newTestPlugin(cfg)
+
+		task := &TaskStatus{config: p.cfg(), ID: "T1", Mode: "direct", Status: "running", StartTime: time.Now(), Options: AnalyzeOptions{Temperature: &temp}}
+		p.runAnalysisDirect(task, "ERROR boom", &pluginsdk.Message{Type: "private", UserID: 1})
+
+		out, _ := os.ReadFile(argsFile)
+		if !strings.Contains(string(out), "--temperature 0.2") {
+			t.Errorf("knot-cli args = %q, want --temperature 0.2", out)
+		}
+	})
+
+	t.Run("proxy", func(t *testing.T) {
+		bodies := make(chan ProxyAnalyzeRequest, 1)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/analyze" {
+				var req ProxyAnalyzeRequest
+				data, _ := io.ReadAll(r.Body)
+				json.Unmarshal(data, &req)
+				bodies <- req
+			}
+		}))
+		defer srv.Close()
+
+		cfg := DefaultConfig()
+		cfg.ProxyURL = srv.URL
+		cfg.TimeoutProxy = 1 // give up before the first status poll
+		p, _ := newTestPlugin(cfg)
+
+		task := &TaskStatus{config: p.cfg(), ID: "T2", Mode: "proxy", Status: "running", StartTime: time.Now(), Options: AnalyzeOptions{Temperature: &temp}}
+		p.runAnalysisViaProxy(task, "ERROR boom", &pluginsdk.Message{Type: "private", UserID: 1})
+
+		select {
+		case req := <-bodies:
+			if req.Temperature == nil || *req.Temperature != temp {
+				t.Errorf("proxy request temperature = %v, want %g", req.Temperature, temp)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("proxy never received an analyze request")
+		}
+	})
+}
+
+func TestNotifyOnStartAfterQueueWait(t *testing.T) {
+	dir := t.TempDir()
+	cli := filepath.Join(dir, "knot-cli")
+	if err := os.WriteFile(cli, []byte("#!/bin/sh\necho done\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := DefaultConfig()
+	cfg.Mode = "direct"
+	cfg.KnotCLIPath = cli
+	cfg.SharedDataPath = dir
+	cfg.MaxConcurrent = 1
+	cfg.NotifyOnStart = true
+	p, bot := newTestPlugin(cfg)
+	msg := &pluginsdk.Message{Type: "private", UserID: 1}
+
+	// Occupy the only slot so the task has to wait
+	p.globalSemaphore() <- struct{}{}
+
+	task := p.createTask(msg, AnalyzeOptions{})
+	finished := make(chan struct{})
+	go func() {
+		p.runAnalysis(task, "ERROR boom", msg)
+		close(finished)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	if sent := bot.sent(); len(sent) != 0 {
+		t.Fatalf("sent %+v while the task was still queued", sent)
+	}
+
+	<-p.globalSemaphore()
+	<-finished
+
+	sent := bot.sent()
+	if len(sent) != 2 || !strings.Contains(sent[0].text, "has started") || !strings.Contains(sent[1].text, "Analysis Completed") {
+		t.Errorf("sent = %+v, want the start notice followed by the result", sent)
+	}
+
+	// A task that gets a slot immediately is not announced
+	task = p.createTask(msg, AnalyzeOptions{})
+	p.runAnalysis(task, "ERROR boom", msg)
+	for _, m := range bot.sent()[2:] {
+		if strings.Contains(m.text, "has started") {
+			t.Errorf("immediate task sent a start notice: %q", m.text)
+		}
+	}
+}
+
+func TestNewProxyTransport(t *testing.T) {
+	transport := newProxyTransport(4, 45)
+	if transport.IdleConnTimeout != 45*time.Second {
+		t.Errorf("IdleConnTimeout = %s, want 45s", transport.IdleConnTimeout)
+	}
+	if transport.MaxIdleConnsPerHost != 4 {
+		t.Errorf("MaxIdleConnsPerHost = %d, want 4", transport.MaxIdleConnsPerHost)
+	}
+
+	if transport := newProxyTransport(0, 0); transport.IdleConnTimeout != 0 {
+		t.Errorf("IdleConnTimeout = %s, want 0 (never reaped)", transport.IdleConnTimeout)
+	}
+}
+
+func TestCreateUserTaskLimitsConcurrentTasksPerUser(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxPerUser = 2
+	p, _ := newTestPlugin(cfg)
+	alice := &pluginsdk.Message{Type: "private", UserID: 1}
+	bob := &pluginsdk.Message{Type: "private", UserID: 2}
+
+	for i := 0; i < 2; i++ {
+		if task, _ := p.createUserTask(alice, AnalyzeOptions{}); task == nil {
+			t.Fatalf("task %d for user 1 was rejected under the limit", i+1)
+		}
+	}
+	if task, err := p.createUserTask(alice, AnalyzeOptions{}); task != nil || err == nil || !strings.Contains(err.Error(), "2 analyses in progress") {
+		t.Errorf("third task for user 1 = %v, %v; want rejection with 2 active", task, err)
+	}
+	if task, _ := p.createUserTask(bob, AnalyzeOptions{}); task == nil {
+		t.Error("user 2 was rejected because of user 1's tasks")
+	}
+
+	// Finished tasks free their slot
+	for _, task := range p.tasks {
+		if task.UserID == 1 {
+			task.Status = "completed"
+			break
+		}
+	}
+	if task, _ := p.createUserTask(alice, AnalyzeOptions{}); task == nil {
+		t.Error("user 1 was rejected after a task finished")
+	}
+}
+
+func TestBackendCooldown(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.PostFailureCooldownSec = 30
+	p, bot := newTestPlugin(cfg)
+	msg := &pluginsdk.Message{Type: "private", UserID: 1}
+
+	finish := func(err error) *TaskStatus {
+		task, _ := p.createUserTask(msg, AnalyzeOptions{})
+		p.finishTask(task, err)
+		return task
+	}
+
+	finish(withCategory(errorCategoryTimeout, errAnalysisTimeout))
+	if wait := p.backendCooldownRemaining(time.Now()); wait > 0 {
+		t.Errorf("timeout started a cooldown of %v", wait)
+	}
+
+	failed := finish(withCategory(errorCategoryBackend, errors.New("proxy error")))
+	if wait := p.backendCooldownRemaining(failed.EndTime); wait != 30*time.Second {
+		t.Errorf("cooldown after backend failure = %v, want 30s", wait)
+	}
+	if wait := p.backendCooldownRemaining(failed.EndTime.Add(31 * time.Second)); wait > 0 {
+		t.Errorf("cooldown still active after it expired: %v", wait)
+	}
+
+	p.handleAnalyze(context.Background(), p.bot, []string{"ERROR", "boom"}, msg)
+	if sent := bot.sent(); len(sent) != 1 || !strings.Contains(sent[0].text, "Backend recently failed") {
+		t.Errorf("reply during cooldown = %+v, want a rejection", sent)
+	}
+
+	// A fresh success lifts the cooldown immediately
+	finish(nil)
+	if wait := p.backendCooldownRemaining(time.Now()); wait > 0 {
+		t.Errorf("cooldown still active after a success: %v", wait)
+	}
+}
+
+func TestDeliverResultTimesOutOnSlowBackend(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.DeliveryTimeoutSec = 1
+	p, bot := newTestPlugin(cfg)
+	bot.sendGate = make(chan struct{})
+	defer close(bot.sendGate)
+
+	outputPath := filepath.Join(t.TempDir(), "analysis.txt")
+	if err := os.WriteFile(outputPath, []byte("Disk is full"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	msg := &pluginsdk.Message{Type: "private", UserID: 1}
+	task, _ := p.createUserTask(msg, AnalyzeOptions{})
+
+	start := time.Now()
+	p.deliverResult(task, outputPath, "Disk is full", msg)
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Fatalf("deliverResult took %v, want it bounded by the 1s delivery timeout", elapsed)
+	}
+
+	p.taskMutex.RLock()
+	undelivered, resultPath := task.Undelivered, task.resultPath
+	p.taskMutex.RUnlock()
+	if !undelivered || resultPath != outputPath {
+		t.Fatalf("task undelivered = %v, resultPath = %q; want it marked undelivered with its result file", undelivered, resultPath)
+	}
+
+	// The undelivered result can be fetched again
+	p.handleResult(p.bot, []string{task.ID}, msg)
+	if up := bot.uploaded(); len(up) != 1 || up[0].path != outputPath || up[0].userID != 1 {
+		t.Errorf("uploads = %+v, want the result file re-sent to user 1", up)
+	}
+}
+
+func TestExtractRequestID(t *testing.T) {
+	tests := []struct {
+		name   string
+		result string
+		want   string
+	}{
+		{"colon", "requestId: abc-123", "abc-123"},
+		{"colon inside value", "requestId: abc:123", "abc:123"},
+		{"equals", "failed with requestId=req_42 status=500", "req_42"},
+		{"json", `{"requestId": "7f3e-91ab", "code": 500}`, "7f3e-91ab"},
+		{"single quoted", "request_id='xyz789'", "xyz789"},
+		{"dash", "requestId - r-555 timed out", "r-555"},
+		{"underscore and space", "request id: 9988", "9988"},
+		{"case insensitive", "REQUEST_ID: Abc", "Abc"},
+		{"trailing punctuation", "See requestId: abc123.", "abc123"},
+		{"closing brace", "{requestId:abc}", "abc"},
+		{"first match wins", "requestId: one\nrequestId: two", "one"},
+		{"url path", "GET /api/requestid/lookup returned 404", ""},
+		{"no id", "nothing to see here", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractRequestID(tt.result); got != tt.want {
+				t.Errorf("extractRequestID(%q) = %q, want %q", tt.result, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModelFor(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Model = "default-model"
+	p, _ := newTestPlugin(cfg)
+
+	tests := []struct {
+		name  string
+		model string
+		want  string
+	}{
+		{"config default", "", "default-model"},
+		{"task override", "preset-model", "preset-model"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &TaskStatus{config: p.cfg(), ID: "T1", Options: AnalyzeOptions{Model: tt.model}}
+
+			args := strings.Join(p.buildCLIArgs(task, "", "log"), " ")
+			if !strings.Contains(args, "--model "+tt.want) {
+				t.Errorf("knot-cli args = %q, want --model %s", args, tt.want)
+			}
+			if req := p.buildProxyRequest(task, "log"); req.Model != tt.want {
+				t.Errorf("proxy request model = %q, want %q", req.Model, tt.want)
+			}
+		})
+	}
+
+	// Results from different models must not share a cache entry
+	a := &TaskStatus{config: p.cfg(), ID: "A"}
+	b := &TaskStatus{config: p.cfg(), ID: "B", Options: AnalyzeOptions{Model: "preset-model"}}
+	if p.cacheKeyFor(a, "prompt") == p.cacheKeyFor(b, "prompt") {
+		t.Error("cache key ignores the task's model")
+	}
+}
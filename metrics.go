@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// durationBuckets are the upper bounds (seconds) of the task duration histogram
+var durationBuckets = []float64{5, 10, 30, 60, 120, 300, 600}
+
+// modeOutcomes are the outcome label values of loganalyzer_mode_tasks_total
+var modeOutcomes = []string{"created", "completed", "failed", "timed_out", "cancelled"}
+
+// Metrics holds plugin-level counters shared by all metric exporters
+// The Prometheus collectors are the source of truth; Snapshot reads them back for JSON
+type Metrics struct {
+	registry *prometheus.Registry
+
+	tasksCreated   prometheus.Counter
+	tasksCompleted prometheus.Counter
+	tasksFailed    prometheus.Counter
+	tasksTimedOut  prometheus.Counter
+	tasksCancelled prometheus.Counter
+	duration       prometheus.Histogram
+	byMode         *prometheus.CounterVec
+
+	mu    sync.Mutex
+	modes map[string]bool // modes seen so far, for Snapshot
+}
+
+// ModeCounts are the task outcome counters of one analysis mode
+type ModeCounts struct {
+	Created   int64 `json:"created"`
+	Completed int64 `json:"completed"`
+	Failed    int64 `json:"failed"`
+	TimedOut  int64 `json:"timed_out"`
+	Cancelled int64 `json:"cancelled"`
+}
+
+// MetricsSnapshot is a point-in-time copy of the metrics
+type MetricsSnapshot struct {
+	TasksCreated   int64             `json:"tasks_created"`
+	TasksCompleted int64             `json:"tasks_completed"`
+	TasksFailed    int64             `json:"tasks_failed"`
+	TasksTimedOut  int64             `json:"tasks_timed_out"`
+	TasksCancelled int64             `json:"tasks_cancelled"`
+	TasksInFlight  int               `json:"tasks_in_flight"`
+	Duration       HistogramSnapshot `json:"duration_seconds"`
+
+	ByMode map[string]ModeCounts `json:"by_mode"`
+}
+
+// HistogramSnapshot is a cumulative histogram keyed by bucket upper bound
+type HistogramSnapshot struct {
+	Buckets map[string]int64 `json:"buckets"`
+	Sum     float64          `json:"sum"`
+	Count   int64            `json:"count"`
+}
+
+// NewMetrics creates an empty metrics set on its own registry
+// inFlight reports the concurrency slots currently held, for the in-flight gauge
+func NewMetrics(inFlight func() int) *Metrics {
+	counter := func(name, help string) prometheus.Counter {
+		return prometheus.NewCounter(prometheus.CounterOpts{Name: name, Help: help})
+	}
+	m := &Metrics{
+		registry:       prometheus.NewRegistry(),
+		tasksCreated:   counter("loganalyzer_tasks_created_total", "Analysis tasks created."),
+		tasksCompleted: counter("loganalyzer_tasks_completed_total", "Analysis tasks completed successfully."),
+		tasksFailed:    counter("loganalyzer_tasks_failed_total", "Analysis tasks failed, including timeouts."),
+		tasksTimedOut:  counter("loganalyzer_tasks_timed_out_total", "Analysis tasks that timed out."),
+		tasksCancelled: counter("loganalyzer_tasks_cancelled_total", "Analysis tasks cancelled before finishing."),
+		duration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "loganalyzer_task_duration_seconds",
+			Help:    "Duration of finished analysis tasks.",
+			Buckets: durationBuckets,
+		}),
+		byMode: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "loganalyzer_mode_tasks_total",
+			Help: "Analysis tasks by mode and outcome; failed includes timed_out.",
+		}, []string{"mode", "outcome"}),
+		modes: make(map[string]bool),
+	}
+	m.registry.MustRegister(
+		m.tasksCreated, m.tasksCompleted, m.tasksFailed, m.tasksTimedOut, m.tasksCancelled,
+		m.duration, m.byMode,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "loganalyzer_tasks_in_flight",
+			Help: "Concurrency slots currently held.",
+		}, func() float64 { return float64(inFlight()) }),
+	)
+	return m
+}
+
+// countMode increments one outcome of a mode, creating all of its series on first use
+// so every outcome is exported (as 0) once the mode has been seen
+func (m *Metrics) countMode(mode, outcome string) {
+	m.mu.Lock()
+	if !m.modes[mode] {
+		m.modes[mode] = true
+		for _, o := range modeOutcomes {
+			m.byMode.WithLabelValues(mode, o)
+		}
+	}
+	m.mu.Unlock()
+	m.byMode.WithLabelValues(mode, outcome).Inc()
+}
+
+// TaskCreated records a newly created task in the given mode
+func (m *Metrics) TaskCreated(mode string) {
+	m.tasksCreated.Inc()
+	m.countMode(mode, "created")
+}
+
+// TaskFinished records a finished task with its mode, final status and duration
+// Cancelled tasks are counted separately and stay out of the duration histogram
+func (m *Metrics) TaskFinished(mode, status string, timedOut bool, d time.Duration) {
+	switch {
+	case status == "cancelled":
+		m.tasksCancelled.Inc()
+		m.countMode(mode, "cancelled")
+		return
+	case timedOut:
+		m.tasksTimedOut.Inc()
+		m.tasksFailed.Inc()
+		m.countMode(mode, "timed_out")
+		m.countMode(mode, "failed")
+	case status == "failed":
+		m.tasksFailed.Inc()
+		m.countMode(mode, "failed")
+	default:
+		m.tasksCompleted.Inc()
+		m.countMode(mode, "completed")
+	}
+	m.duration.Observe(d.Seconds())
+}
+
+// Snapshot returns a copy of the current metrics
+func (m *Metrics) Snapshot(inFlight int) MetricsSnapshot {
+	var hist dto.Metric
+	m.duration.Write(&hist)
+	buckets := make(map[string]int64, len(durationBuckets)+1)
+	for _, b := range hist.GetHistogram().GetBucket() {
+		buckets[strconv.FormatFloat(b.GetUpperBound(), 'f', -1, 64)] = int64(b.GetCumulativeCount())
+	}
+	count := int64(hist.GetHistogram().GetSampleCount())
+	buckets["+Inf"] = count
+
+	m.mu.Lock()
+	modes := make([]string, 0, len(m.modes))
+	for mode := range m.modes {
+		modes = append(modes, mode)
+	}
+	m.mu.Unlock()
+
+	byMode := make(map[string]ModeCounts, len(modes))
+	for _, mode := range modes {
+		outcome := func(o string) int64 { return counterValue(m.byMode.WithLabelValues(mode, o)) }
+		byMode[mode] = ModeCounts{
+			Created:   outcome("created"),
+			Completed: outcome("completed"),
+			Failed:    outcome("failed"),
+			TimedOut:  outcome("timed_out"),
+			Cancelled: outcome("cancelled"),
+		}
+	}
+
+	return MetricsSnapshot{
+		TasksCreated:   counterValue(m.tasksCreated),
+		TasksCompleted: counterValue(m.tasksCompleted),
+		TasksFailed:    counterValue(m.tasksFailed),
+		TasksTimedOut:  counterValue(m.tasksTimedOut),
+		TasksCancelled: counterValue(m.tasksCancelled),
+		TasksInFlight:  inFlight,
+		Duration: HistogramSnapshot{
+			Buckets: buckets,
+			Sum:     hist.GetHistogram().GetSampleSum(),
+			Count:   count,
+		},
+		ByMode: byMode,
+	}
+}
+
+// counterValue reads the current value of a counter
+func counterValue(c prometheus.Counter) int64 {
+	var metric dto.Metric
+	c.Write(&metric)
+	return int64(metric.GetCounter().GetValue())
+}
+
+// startMetricsServer starts the HTTP server exposing metrics on MetricsAddr
+func (p *LogAnalyzerPlugin) startMetricsServer() {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(p.metrics.registry, promhttp.HandlerOpts{}))
+	mux.HandleFunc("/metrics.json", p.handleMetricsJSON)
+	if p.cfg().APIToken != "" {
+		p.registerTaskAPI(mux)
+	}
+
+	p.metricsServer = &http.Server{
+		Addr:    p.cfg().MetricsAddr,
+		Handler: mux,
+	}
+
+	go func() {
+		if err := p.metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			p.logf("error", "Metrics server error: %v", err)
+		}
+	}()
+	p.logf("info", "  metrics: %s", p.cfg().MetricsAddr)
+}
+
+// stopMetricsServer shuts the metrics server down
+func (p *LogAnalyzerPlugin) stopMetricsServer() {
+	if p.metricsServer == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	p.metricsServer.Shutdown(ctx)
+}
+
+// handleMetricsJSON serves the metrics as a JSON object
+func (p *LogAnalyzerPlugin) handleMetricsJSON(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(p.metrics.Snapshot(len(p.globalSemaphore())))
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func TestMetricsJSONAfterTasks(t *testing.T) {
+	p, _ := newTestPlugin(DefaultConfig())
+	p.globalSemaphore() <- struct{}{}
+
+	start := time.Now().Add(-20 * time.Second)
+	for i, err := range []error{nil, fmt.Errorf("knot-cli: %w", errAnalysisTimeout)} {
+		p.metrics.TaskCreated("direct")
+		task := &TaskStatus{config: p.cfg(), ID: fmt.Sprintf("T%d", i), Mode: "direct", Status: "running", StartTime: start}
+		p.finishTask(task, err)
+	}
+
+	rec := httptest.NewRecorder()
+	p.handleMetricsJSON(rec, httptest.NewRequest("GET", "/metrics.json", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	var got struct {
+		TasksCreated   int64 `json:"tasks_created"`
+		TasksCompleted int64 `json:"tasks_completed"`
+		TasksFailed    int64 `json:"tasks_failed"`
+		TasksTimedOut  int64 `json:"tasks_timed_out"`
+		TasksInFlight  int   `json:"tasks_in_flight"`
+		Duration       struct {
+			Buckets map[string]int64 `json:"buckets"`
+			Count   int64            `json:"count"`
+		} `json:"duration_seconds"`
+		ByMode map[string]ModeCounts `json:"by_mode"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("metrics are not JSON: %v\n%s", err, rec.Body)
+	}
+
+	if got.TasksCreated != 2 || got.TasksCompleted != 1 || got.TasksFailed != 1 || got.TasksTimedOut != 1 || got.TasksInFlight != 1 {
+		t.Errorf("counters = %+v", got)
+	}
+	if got.Duration.Count != 2 || got.Duration.Buckets["10"] != 0 || got.Duration.Buckets["30"] != 2 || got.Duration.Buckets["+Inf"] != 2 {
+		t.Errorf("duration histogram = %+v", got.Duration)
+	}
+	if direct := got.ByMode["direct"]; direct.Created != 2 || direct.Completed != 1 || direct.Failed != 1 || direct.TimedOut != 1 || len(got.ByMode) != 1 {
+		t.Errorf("per-mode counters = %+v", got.ByMode)
+	}
+}
+
+func TestMetricsExposition(t *testing.T) {
+	m := NewMetrics(func() int { return 2 })
+	m.TaskCreated("proxy")
+	m.TaskCreated("proxy")
+	m.TaskCreated("proxy")
+	m.TaskFinished("proxy", "completed", false, 7*time.Second)
+	m.TaskFinished("proxy", "failed", true, 400*time.Second)
+	m.TaskFinished("proxy", "cancelled", false, time.Second)
+
+	rec := httptest.NewRecorder()
+	promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+
+	for _, want := range []string{
+		"loganalyzer_tasks_created_total 3",
+		"loganalyzer_tasks_completed_total 1",
+		"loganalyzer_tasks_failed_total 1",
+		"loganalyzer_tasks_timed_out_total 1",
+		"loganalyzer_tasks_cancelled_total 1",
+		"loganalyzer_tasks_in_flight 2",
+		`loganalyzer_mode_tasks_total{mode="proxy",outcome="cancelled"} 1`,
+		`loganalyzer_mode_tasks_total{mode="proxy",outcome="timed_out"} 1`,
+		`loganalyzer_task_duration_seconds_bucket{le="10"} 1`,
+		`loganalyzer_task_duration_seconds_bucket{le="+Inf"} 2`,
+		"loganalyzer_task_duration_seconds_count 2",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("exposition is missing %q", want)
+		}
+	}
+}
+
+func TestMetricsSnapshot(t *testing.T) {
+	m := NewMetrics(func() int { return 0 })
+	m.TaskCreated("direct")
+	m.TaskFinished("direct", "completed", false, 20*time.Second)
+
+	snap := m.Snapshot(1)
+	if snap.TasksCreated != 1 || snap.TasksCompleted != 1 || snap.TasksInFlight != 1 {
+		t.Errorf("snapshot totals = %+v", snap)
+	}
+	if got := snap.ByMode["direct"]; got != (ModeCounts{Created: 1, Completed: 1}) {
+		t.Errorf("direct mode counts = %+v", got)
+	}
+	if snap.Duration.Buckets["10"] != 0 || snap.Duration.Buckets["30"] != 1 || snap.Duration.Buckets["+Inf"] != 1 {
+		t.Errorf("duration buckets = %v", snap.Duration.Buckets)
+	}
+	if snap.Duration.Sum != 20 {
+		t.Errorf("duration sum = %g, want 20", snap.Duration.Sum)
+	}
+}
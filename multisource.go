@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// sectionHeaderPattern matches the per-source headers of combined multi-source logs
+var sectionHeaderPattern = regexp.MustCompile(`^=== .+ ===$`)
+
+// stackFramePattern matches common stack frame lines (Java, Python, Go, native)
+var stackFramePattern = regexp.MustCompile(`^\s*(at\s+\S+\(.*\)|File ".*", line \d+|\S+\.go:\d+( \+0x[0-9a-f]+)?|#\d+\s+0x[0-9a-fA-F]+.*|\.\.\. \d+ more)\s*$`)
+
+// minDedupFrames is the shortest run of repeated frames worth collapsing
+const minDedupFrames = 2
+
+// sectionHeader returns the header introducing one source in a combined log
+func sectionHeader(name string) string {
+	return fmt.Sprintf("=== %s ===", name)
+}
+
+// countSections returns the number of source sections in a combined log
+func countSections(content string) int {
+	n := 0
+	for _, line := range strings.Split(content, "\n") {
+		if sectionHeaderPattern.MatchString(strings.TrimSpace(line)) {
+			n++
+		}
+	}
+	return n
+}
+
+// dedupStackFrames collapses stack frames already seen in an earlier section
+// Runs of repeated frames in later sections become "(same framework frames as above)"
+func dedupStackFrames(content string) string {
+	lines := strings.Split(content, "\n")
+	seen := make(map[string]bool)    // frames from previous sections
+	current := make(map[string]bool) // frames from the section being scanned
+
+	var out []string
+	var run []string
+	flush := func() {
+		if len(run) >= minDedupFrames {
+			out = append(out, "\t(same framework frames as above)")
+		} else {
+			out = append(out, run...)
+		}
+		run = nil
+	}
+
+	for _, line := range lines {
+		if sectionHeaderPattern.MatchString(strings.TrimSpace(line)) {
+			flush()
+			for frame := range current {
+				seen[frame] = true
+			}
+			current = make(map[string]bool)
+			out = append(out, line)
+			continue
+		}
+
+		if stackFramePattern.MatchString(line) {
+			frame := strings.TrimSpace(line)
+			current[frame] = true
+			if seen[frame] {
+				run = append(run, line)
+				continue
+			}
+		}
+
+		flush()
+		out = append(out, line)
+	}
+	flush()
+
+	return strings.Join(out, "\n")
+}
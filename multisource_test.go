@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+func TestDedupStackFrames(t *testing.T) {
+	in := `=== api.log ===
+ERROR request failed
+	at com.acme.api.Handler.serve(Handler.java:42)
+	at org.framework.Dispatcher.dispatch(Dispatcher.java:100)
+	at org.framework.Server.run(Server.java:200)
+=== worker.log ===
+ERROR job failed
+	at com.acme.worker.Job.run(Job.java:7)
+	at org.framework.Dispatcher.dispatch(Dispatcher.java:100)
+	at org.framework.Server.run(Server.java:200)
+	at com.acme.api.Handler.serve(Handler.java:42)
+DONE`
+
+	want := `=== api.log ===
+ERROR request failed
+	at com.acme.api.Handler.serve(Handler.java:42)
+	at org.framework.Dispatcher.dispatch(Dispatcher.java:100)
+	at org.framework.Server.run(Server.java:200)
+=== worker.log ===
+ERROR job failed
+	at com.acme.worker.Job.run(Job.java:7)
+	(same framework frames as above)
+DONE`
+
+	if got := dedupStackFrames(in); got != want {
+		t.Errorf("dedupStackFrames =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestDedupStackFramesKeepsSingleRepeats(t *testing.T) {
+	in := "=== a ===\n\tat x.Y.z(Y.java:1)\n=== b ===\n\tat x.Y.z(Y.java:1)\nERROR"
+	if got := dedupStackFrames(in); got != in {
+		t.Errorf("a single repeated frame was collapsed:\n%s", got)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// defaultOutputPathTemplate keeps every result directly under SharedDataPath
+const defaultOutputPathTemplate = "analysis_{id}.txt"
+
+// outputPathFor returns where a task's result is written, creating its directory
+// OutputPathTemplate is relative to SharedDataPath; placeholders: {id}, {group}
+// ("private" outside groups), {user} and {date} (task start date, YYYY-MM-DD)
+func (p *LogAnalyzerPlugin) outputPathFor(task *TaskStatus) (string, error) {
+	tmpl := task.config.OutputPathTemplate
+	if tmpl == "" {
+		tmpl = defaultOutputPathTemplate
+	}
+	group := "private"
+	if task.GroupID != 0 {
+		group = strconv.FormatInt(task.GroupID, 10)
+	}
+	rel := strings.NewReplacer(
+		"{id}", task.ID,
+		"{group}", group,
+		"{user}", strconv.FormatInt(task.UserID, 10),
+		"{date}", task.StartTime.Format("2006-01-02"),
+	).Replace(tmpl)
+
+	rel = filepath.Clean(rel)
+	if filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("output path %q escapes the shared data directory", rel)
+	}
+	path := filepath.Join(task.config.SharedDataPath, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %v", err)
+	}
+
+	p.taskMutex.Lock()
+	task.OutputPath = path
+	p.taskMutex.Unlock()
+	return path, nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// tasksFile is the task history file under SharedDataPath
+const tasksFile = "tasks.json"
+
+// persistDebounce delays writes so bursts of task updates produce one write
+const persistDebounce = time.Second
+
+// errInterruptedByRestart marks tasks that were in flight when the plugin stopped
+var errInterruptedByRestart = errors.New("interrupted by restart")
+
+// tasksPath returns the path of the task history file
+func (p *LogAnalyzerPlugin) tasksPath() string {
+	return filepath.Join(p.cfg().SharedDataPath, tasksFile)
+}
+
+// loadTasks restores task history, failing tasks that were still pending or running
+func (p *LogAnalyzerPlugin) loadTasks() error {
+	data, err := os.ReadFile(p.tasksPath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var tasks []*TaskStatus
+	if err := json.Unmarshal(data, &tasks); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	cfg := p.cfg()
+	p.taskMutex.Lock()
+	defer p.taskMutex.Unlock()
+	for _, task := range tasks {
+		task.config = cfg
+		if task.Status == "pending" || task.Status == "running" {
+			task.Status = "failed"
+			task.Error = errInterruptedByRestart.Error()
+			task.ErrorCategory = errorCategoryInternal
+			task.ErrorCode = errorCodeInterrupted
+			task.EndTime = now
+			task.Duration = now.Sub(task.StartTime).Round(time.Millisecond).String()
+		}
+		p.tasks[task.ID] = task
+	}
+	return nil
+}
+
+// saveTasks writes all tasks to disk, replacing the file atomically
+func (p *LogAnalyzerPlugin) saveTasks() error {
+	p.taskMutex.RLock()
+	tasks := make([]*TaskStatus, 0, len(p.tasks))
+	for _, task := range p.tasks {
+		tasks = append(tasks, task)
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].StartTime.Before(tasks[j].StartTime) })
+	data, err := json.MarshalIndent(tasks, "", "  ")
+	p.taskMutex.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	tmp := p.tasksPath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p.tasksPath())
+}
+
+// schedulePersist requests a debounced write of the task history
+func (p *LogAnalyzerPlugin) schedulePersist() {
+	if p.persistCh == nil {
+		return
+	}
+	select {
+	case p.persistCh <- struct{}{}:
+	default:
+	}
+}
+
+// runPersister writes the task history at most once per persistDebounce
+func (p *LogAnalyzerPlugin) runPersister() {
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-p.persistCh:
+		}
+
+		select {
+		case <-p.done:
+			return
+		case <-time.After(persistDebounce):
+		}
+
+		if err := p.saveTasks(); err != nil {
+			p.logf("warn", "Failed to save tasks: %v", err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// buildPrompt assembles the prompt sent to knot-cli or the proxy for a task
+func (p *LogAnalyzerPlugin) buildPrompt(task *TaskStatus, logContent string) string {
+	prompt := logContent
+
+	// Drop framework frames repeated across sources of a combined log
+	if task.config.DedupStackFrames && countSections(prompt) > 1 {
+		prompt = dedupStackFrames(prompt)
+	}
+
+	// Repeat the latest entries so the model focuses on the current incident
+	emphasized := false
+	if task.config.EmphasizeRecent {
+		if withRecent := emphasizeRecent(prompt, task.config.RecentEntryLines); withRecent != prompt {
+			prompt = withRecent
+			emphasized = true
+		}
+	}
+
+	// Neutralize instructions embedded in suspicious logs
+	if task.config.GuardPromptInjection && looksLikePromptInjection(prompt) {
+		prompt = wrapUntrustedLog(prompt)
+		p.taskMutex.Lock()
+		task.InjectionSuspected = true
+		p.taskMutex.Unlock()
+		p.taskLogf("warn", task.ID, "Log contains instruction-like text, wrapped as untrusted data")
+	}
+
+	// Steer the analysis toward the user's question
+	if task.Options.Question != "" {
+		prompt = fmt.Sprintf("Question: %s\nFocus the analysis on answering this question using the log below.\n\n%s", task.Options.Question, prompt)
+	}
+
+	// One-off steering from --instruction; the proxy receives it as a request field instead
+	if task.Options.Instruction != "" && task.Mode != "proxy" {
+		prompt = fmt.Sprintf("Instruction: %s\n\n%s", task.Options.Instruction, prompt)
+	}
+
+	if emphasized {
+		prompt += fmt.Sprintf("\n\nThe section between %q and %q repeats the latest log entries; weigh them most when identifying the current issue.", recentHeader, recentFooter)
+	}
+
+	// Let the model know context may be missing
+	if task.InputTruncated {
+		prompt += "\n\nNote: this log appears to be truncated or partial. Point out where missing context limits the analysis."
+	}
+
+	// Simplify the explanation for non-specialists
+	if task.Options.ELI5 && task.config.ELI5Suffix != "" {
+		prompt += "\n\n" + task.config.ELI5Suffix
+	}
+
+	// Ask for the result in the group's language
+	if lang := task.config.outputLangFor(task.GroupID); lang != "" {
+		prompt += fmt.Sprintf("\n\nRespond in %s.", lang)
+	}
+
+	return prompt
+}
+
+// outputLangFor returns the result language for a group, falling back to the global setting
+func (c *Config) outputLangFor(groupID int64) string {
+	if lang, ok := c.GroupOutputLang[groupID]; ok && lang != "" {
+		return lang
+	}
+	return c.OutputLang
+}
+
+// truncationPattern matches common markers left by tools that cut logs short
+var truncationPattern = regexp.MustCompile(`(?i)(\[\s*(\.\.\.|…)?\s*truncated\s*(\.\.\.|…)?\s*\]|\(\s*truncated\s*\)|<\s*truncated\s*>|\.\.\.\s*truncated|truncated\s*\.\.\.|\d+\s+(more\s+)?(lines|bytes|chars|characters)\s+(omitted|truncated)|已截断|已省略)`)
+
+// looksTruncated reports whether a log appears to be cut off or contain truncation markers
+func looksTruncated(logContent string) bool {
+	if truncationPattern.MatchString(logContent) {
+		return true
+	}
+	// A trailing ellipsis usually means the paste was cut mid-line
+	trimmed := strings.TrimRight(logContent, " \t\r\n")
+	return strings.HasSuffix(trimmed, "...") || strings.HasSuffix(trimmed, "…")
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DaikonSushi/bot-platform/pkg/pluginsdk"
+)
+
+func TestBuildPromptUsesGroupLanguage(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.OutputLang = "English"
+	cfg.GroupOutputLang = map[int64]string{100: "Chinese"}
+	p, _ := newTestPlugin(cfg)
+
+	tests := []struct {
+		name    string
+		groupID int64
+		want    string
+	}{
+		{"group override", 100, "Respond in Chinese."},
+		{"global default", 200, "Respond in English."},
+		{"private chat", 0, "Respond in English."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prompt := p.buildPrompt(&TaskStatus{config: p.cfg(), GroupID: tt.groupID}, "panic: boom")
+			if !strings.HasSuffix(prompt, "\n\n"+tt.want) {
+				t.Errorf("prompt = %q, want it to end with %q", prompt, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildPromptUsesTaskLanguageAfterReload(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GroupOutputLang = map[int64]string{100: "Chinese"}
+	p, _ := newTestPlugin(cfg)
+	task := &TaskStatus{config: p.cfg(), GroupID: 100}
+
+	// A reload after the task was created must not change its language
+	reloaded := DefaultConfig()
+	reloaded.OutputLang = "English"
+	p.buildDerivedState(&reloaded, p.cfg())
+	p.config.Store(&reloaded)
+
+	if prompt := p.buildPrompt(task, "panic: boom"); !strings.HasSuffix(prompt, "\n\nRespond in Chinese.") {
+		t.Errorf("prompt = %q, want the language of the task's own configuration", prompt)
+	}
+}
+
+func TestBuildPromptWithoutLanguage(t *testing.T) {
+	p, _ := newTestPlugin(DefaultConfig())
+	if got := p.buildPrompt(&TaskStatus{config: p.cfg(), GroupID: 100}, "panic: boom"); got != "panic: boom" {
+		t.Errorf("prompt = %q, want the log unchanged", got)
+	}
+}
+
+func TestParseGroupMap(t *testing.T) {
+	got := parseGroupMap("123=Chinese, 456 = English ,bad=x,789=,nopair")
+	if len(got) != 2 || got[123] != "Chinese" || got[456] != "English" {
+		t.Errorf("parseGroupMap = %v", got)
+	}
+}
+
+func TestLooksTruncated(t *testing.T) {
+	tests := []struct {
+		log  string
+		want bool
+	}{
+		{"ERROR a\n[... truncated ...]\nERROR b", true},
+		{"ERROR a\n<truncated>", true},
+		{"at Foo.bar\n\t... 42 more", false},
+		{"at Foo.bar\n42 more lines omitted", true},
+		{"stack: 已截断", true},
+		{"ERROR connection reset by peer...", true},
+		{"ERROR connection reset by peer\n", false},
+	}
+	for _, tt := range tests {
+		if got := looksTruncated(tt.log); got != tt.want {
+			t.Errorf("looksTruncated(%q) = %v, want %v", tt.log, got, tt.want)
+		}
+	}
+}
+
+func TestBuildPromptNotesTruncatedInput(t *testing.T) {
+	p, _ := newTestPlugin(DefaultConfig())
+	log := "ERROR a\n[... truncated ...]"
+
+	task := &TaskStatus{config: p.cfg(), InputTruncated: looksTruncated(log)}
+	if prompt := p.buildPrompt(task, log); !strings.Contains(prompt, "appears to be truncated") {
+		t.Errorf("prompt = %q, want the truncation note", prompt)
+	}
+	if prompt := p.buildPrompt(&TaskStatus{config: p.cfg()}, "ERROR a"); strings.Contains(prompt, "truncated") {
+		t.Errorf("prompt = %q, want no truncation note", prompt)
+	}
+}
+
+func TestBuildPromptELI5(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ELI5Suffix = "Explain it simply."
+	p, _ := newTestPlugin(cfg)
+
+	opts, rest, err := p.parseAnalyzeArgs([]string{"--eli5", "ERROR", "boom"})
+	if err != nil || !opts.ELI5 {
+		t.Fatalf("parseAnalyzeArgs = %+v, %v", opts, err)
+	}
+	prompt := p.buildPrompt(&TaskStatus{config: p.cfg(), Options: opts}, strings.Join(rest, " "))
+	if !strings.HasSuffix(prompt, "\n\nExplain it simply.") {
+		t.Errorf("prompt = %q, want the ELI5 instruction appended", prompt)
+	}
+
+	if prompt := p.buildPrompt(&TaskStatus{config: p.cfg()}, "ERROR boom"); strings.Contains(prompt, "Explain it simply.") {
+		t.Errorf("prompt = %q, want no ELI5 instruction without the flag", prompt)
+	}
+}
+
+func TestBuildPromptAsk(t *testing.T) {
+	p, bot := newTestPlugin(DefaultConfig())
+
+	opts, rest, err := p.parseAnalyzeArgs([]string{"--ask", `"why`, "is", `latency spiking?"`, "WARN", "slow"})
+	if err != nil || opts.Question != "why is latency spiking?" || strings.Join(rest, " ") != "WARN slow" {
+		t.Fatalf("parseAnalyzeArgs = %+v, %q, %v", opts, rest, err)
+	}
+	task := &TaskStatus{config: p.cfg(), ID: "T1", Options: opts}
+	prompt := p.buildPrompt(task, strings.Join(rest, " "))
+	if !strings.HasPrefix(prompt, "Question: why is latency spiking?\n") || !strings.Contains(prompt, "WARN slow") {
+		t.Errorf("prompt = %q, want the question ahead of the log", prompt)
+	}
+
+	p.sendResult(task, "", "GC pauses", &pluginsdk.Message{Type: "private", UserID: 1})
+	if sent := bot.sent(); len(sent) != 1 || !strings.Contains(sent[0].text, "❓ Question: why is latency spiking?\n") {
+		t.Errorf("reply = %+v, want the question echoed", sent)
+	}
+
+	for _, args := range [][]string{{"--ask"}, {"--ask", `"unterminated`, "log"}, {"--ask=", "log"}} {
+		if _, _, err := p.parseAnalyzeArgs(args); err == nil {
+			t.Errorf("parseAnalyzeArgs(%q) succeeded, want an error", args)
+		}
+	}
+}
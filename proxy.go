@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// normalizeProxyURL validates one proxy base URL and returns it in canonical form:
+// "http://" is assumed when the scheme is missing and trailing slashes are trimmed
+func normalizeProxyURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return "", errors.New("missing host")
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return "", errors.New("query and fragment are not allowed")
+	}
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = ""
+	return u.String(), nil
+}
+
+// normalizeProxyURLs normalizes each instance of a comma-separated ProxyURL, dropping
+// invalid ones with a warning
+func (p *LogAnalyzerPlugin) normalizeProxyURLs(spec string) string {
+	var valid []string
+	for _, raw := range parseProxyURLs(spec) {
+		u, err := normalizeProxyURL(raw)
+		if err != nil {
+			p.logf("warn", "Ignoring invalid proxy URL %q: %v", raw, err)
+			continue
+		}
+		valid = append(valid, u)
+	}
+	return strings.Join(valid, ",")
+}
+
+// proxyEndpoint joins path elements onto a proxy base URL, escaping each element
+// Bases are validated at config load, so a join error leaves the URL empty and the
+// request fails when it is built
+func proxyEndpoint(base string, elem ...string) string {
+	u, _ := url.JoinPath(base, elem...)
+	return u
+}
+
+// parseProxyURLs splits a comma-separated ProxyURL into proxy instance base URLs
+func parseProxyURLs(spec string) []string {
+	var urls []string
+	for _, u := range strings.Split(spec, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
+// proxyOrder returns the proxy instances to try for a new task
+// The starting instance rotates per task to spread load; the rest follow as failover
+func (p *LogAnalyzerPlugin) proxyOrder() []string {
+	n := len(p.cfg().proxyURLs)
+	if n == 0 {
+		return nil
+	}
+	start := int(p.proxyNext.Add(1)-1) % n
+	order := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		order = append(order, p.cfg().proxyURLs[(start+i)%n])
+	}
+	return order
+}
+
+// proxyPostAttempts is how many times the initial /analyze request is tried
+const proxyPostAttempts = 3
+
+// proxyPostBackoff is the delay before the first retry, doubled for each further retry
+const proxyPostBackoff = time.Second
+
+// newProxyRequest builds a request to a proxy instance carrying the configured API key
+func (p *LogAnalyzerPlugin) newProxyRequest(ctx context.Context, method, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	if p.cfg().ProxyAPIKey != "" {
+		header := p.cfg().ProxyAuthHeader
+		if header == "" {
+			header = "Authorization"
+		}
+		if strings.EqualFold(header, "Authorization") {
+			req.Header.Set(header, "Bearer "+p.cfg().ProxyAPIKey)
+		} else {
+			req.Header.Set(header, p.cfg().ProxyAPIKey)
+		}
+	}
+	// Requested explicitly so compressed responses are decoded the same way whatever
+	// the transport does; see decodeProxyBody
+	req.Header.Set("Accept-Encoding", "gzip")
+	return req, nil
+}
+
+// cancelOnClose releases a request's timeout context once its response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close closes the body and cancels the request context
+func (c cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+// gzipBody reads a gzip-encoded response body and closes the underlying body with it
+type gzipBody struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+// Close closes the gzip reader and the response body
+func (b gzipBody) Close() error {
+	b.Reader.Close()
+	return b.body.Close()
+}
+
+// decodeProxyBody transparently decompresses a gzip-encoded proxy response
+func decodeProxyBody(resp *http.Response) error {
+	if !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		return nil
+	}
+	zr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		resp.Body.Close()
+		return fmt.Errorf("invalid gzip response: %v", err)
+	}
+	resp.Body = gzipBody{Reader: zr, body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
+
+// doProxyRequest sends a proxy request bounded by HTTPRequestTimeoutSec, so a stuck
+// connection fails fast instead of using up the task's whole analysis timeout
+// The timeout covers reading the body as well; it ends when the body is closed
+func (p *LogAnalyzerPlugin) doProxyRequest(req *http.Request) (*http.Response, error) {
+	cancel := context.CancelFunc(func() {})
+	if p.cfg().HTTPRequestTimeoutSec > 0 {
+		var ctx context.Context
+		ctx, cancel = context.WithTimeout(req.Context(), time.Duration(p.cfg().HTTPRequestTimeoutSec)*time.Second)
+		req = req.WithContext(ctx)
+	}
+	resp, err := p.cfg().httpClient.Do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	if err := decodeProxyBody(resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// postAnalyzeRequest sends the /analyze request, retrying connection errors and 5xx responses
+// 4xx responses are returned to the caller without retrying
+func (p *LogAnalyzerPlugin) postAnalyzeRequest(ctx context.Context, taskID, url string, body []byte) (*http.Response, error) {
+	var lastErr error
+	backoff := proxyPostBackoff
+
+	for attempt := 1; attempt <= proxyPostAttempts; attempt++ {
+		req, err := p.newProxyRequest(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to build analyze request: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := p.doProxyRequest(req)
+		if err == nil && resp.StatusCode < http.StatusInternalServerError {
+			return resp, nil
+		}
+		if err != nil {
+			lastErr = withCategory(errorCategoryConnection, fmt.Errorf("failed to connect to proxy: %v", err))
+		} else {
+			resp.Body.Close()
+			lastErr = withCategory(errorCategoryBackend, fmt.Errorf("proxy returned %s", resp.Status))
+		}
+
+		if attempt == proxyPostAttempts {
+			break
+		}
+		p.taskLogf("warn", taskID, "Analyze request attempt %d/%d failed: %v", attempt, proxyPostAttempts, lastErr)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+
+	return nil, fmt.Errorf("%w (after %d attempts)", lastErr, proxyPostAttempts)
+}
+
+// checkAnalyzeResponse decodes the /analyze response and reports whether the proxy rejected the job
+func checkAnalyzeResponse(resp *http.Response) error {
+	defer resp.Body.Close()
+
+	var result ProxyAnalyzeResponse
+	decodeErr := json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&result)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if decodeErr == nil && result.Error != "" {
+			return withCategory(errorCategoryBackend, fmt.Errorf("proxy rejected request (%s): %s", resp.Status, result.Error))
+		}
+		return withCategory(errorCategoryBackend, fmt.Errorf("proxy rejected request: %s", resp.Status))
+	}
+	if decodeErr != nil && decodeErr != io.EOF {
+		return withCategory(errorCategoryBackend, fmt.Errorf("failed to decode proxy response: %v", decodeErr))
+	}
+	if result.Status == "failed" || result.Error != "" {
+		return withCategory(errorCategoryBackend, fmt.Errorf("proxy error: %s", result.Error))
+	}
+	return nil
+}
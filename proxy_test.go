@@ -0,0 +1,135 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNormalizeProxyURL(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{"http://proxy:9999", "http://proxy:9999", false},
+		{"http://proxy:9999/", "http://proxy:9999", false},
+		{"http://proxy:9999///", "http://proxy:9999", false},
+		{"https://proxy.example.com/knot/", "https://proxy.example.com/knot", false},
+		{"proxy:9999", "http://proxy:9999", false},
+		{"host.docker.internal:9999/", "http://host.docker.internal:9999", false},
+		{"  http://proxy:9999  ", "http://proxy:9999", false},
+		{"ftp://proxy:9999", "", true},
+		{"http://", "", true},
+		{"http://proxy:9999/?debug=1", "", true},
+		{"http://proxy:9999/#top", "", true},
+		{"http://[::1", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := normalizeProxyURL(tt.raw)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("normalizeProxyURL(%q) = %q, %v; want %q, error %v", tt.raw, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestProxyEndpointWellFormed(t *testing.T) {
+	tests := []struct {
+		base string
+		elem []string
+		want string
+	}{
+		{"http://proxy:9999", []string{"analyze"}, "http://proxy:9999/analyze"},
+		{"http://proxy:9999/", []string{"analyze"}, "http://proxy:9999/analyze"},
+		{"http://proxy:9999/knot", []string{"status", "A1B2C3D4"}, "http://proxy:9999/knot/status/A1B2C3D4"},
+		{"http://proxy:9999", []string{"status", "a b"}, "http://proxy:9999/status/a%20b"},
+	}
+
+	for _, tt := range tests {
+		if got := proxyEndpoint(tt.base, tt.elem...); got != tt.want {
+			t.Errorf("proxyEndpoint(%q, %q) = %q, want %q", tt.base, tt.elem, got, tt.want)
+		}
+	}
+}
+
+// newTestProxyPlugin returns a plugin whose proxy requests go to srv
+func newTestProxyPlugin(srv *httptest.Server) *LogAnalyzerPlugin {
+	p := &LogAnalyzerPlugin{}
+	p.config.Store(&Config{httpClient: srv.Client()})
+	return p
+}
+
+func TestDoProxyRequestDecodesGzipStatus(t *testing.T) {
+	want := ProxyStatusResponse{RequestID: "A1B2C3D4", Status: "completed", Content: "root cause: nil map", ContentSize: 19}
+
+	tests := []struct {
+		name string
+		gzip bool
+	}{
+		{"gzip", true},
+		{"identity", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if got := r.Header.Get("Accept-Encoding"); got != "gzip" {
+					t.Errorf("Accept-Encoding = %q, want gzip", got)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				if !tt.gzip {
+					json.NewEncoder(w).Encode(want)
+					return
+				}
+				w.Header().Set("Content-Encoding", "gzip")
+				zw := gzip.NewWriter(w)
+				json.NewEncoder(zw).Encode(want)
+				zw.Close()
+			}))
+			defer srv.Close()
+
+			p := newTestProxyPlugin(srv)
+			req, err := p.newProxyRequest(context.Background(), http.MethodGet, proxyEndpoint(srv.URL, "status", want.RequestID), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := p.doProxyRequest(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			var got ProxyStatusResponse
+			if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+				t.Fatalf("decode status: %v", err)
+			}
+			if got != want {
+				t.Errorf("status = %+v, want %+v", got, want)
+			}
+			if !contentSizeMatches(got) {
+				t.Error("content size does not match the decompressed content")
+			}
+		})
+	}
+}
+
+func TestDoProxyRequestRejectsInvalidGzip(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write([]byte("not gzip"))
+	}))
+	defer srv.Close()
+
+	p := newTestProxyPlugin(srv)
+	req, err := p.newProxyRequest(context.Background(), http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.doProxyRequest(req); err == nil {
+		t.Error("doProxyRequest accepted an invalid gzip body")
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DaikonSushi/bot-platform/pkg/pluginsdk"
+)
+
+// liveTasks returns the running tasks by run start, then the pending ones by submission
+func (p *LogAnalyzerPlugin) liveTasks() (running, pending []TaskStatus) {
+	p.taskMutex.RLock()
+	defer p.taskMutex.RUnlock()
+
+	for _, task := range p.tasks {
+		switch task.Status {
+		case "running":
+			running = append(running, *task)
+		case "pending":
+			pending = append(pending, *task)
+		}
+	}
+	sort.Slice(running, func(i, j int) bool { return running[i].RunStartTime.Before(running[j].RunStartTime) })
+	sort.Slice(pending, func(i, j int) bool { return pending[i].StartTime.Before(pending[j].StartTime) })
+	return running, pending
+}
+
+// handleQueue handles the analyzequeue admin command
+// Running tasks show the time since they started running, pending ones since submission
+func (p *LogAnalyzerPlugin) handleQueue(bot *pluginsdk.BotClient, msg *pluginsdk.Message) {
+	if !p.isAdmin(msg.UserID) {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.admin_only")))
+		return
+	}
+
+	running, pending := p.liveTasks()
+	now := time.Now()
+	sem := p.globalSemaphore()
+
+	var sb strings.Builder
+	sb.WriteString(p.msgf("queue.title") + "\n")
+	sb.WriteString("━━━━━━━━━━━━━━━━━━━━\n")
+	sb.WriteString(p.msgf("queue.slots", len(sem), cap(sem)) + "\n")
+
+	sb.WriteString(fmt.Sprintf("\n%s %s (%d)\n", getStatusIcon("running"), p.statusLabel("running"), len(running)))
+	for _, task := range running {
+		since := task.RunStartTime
+		if since.IsZero() {
+			since = task.StartTime
+		}
+		sb.WriteString(p.msgf("queue.running_item", task.ID, task.UserID, now.Sub(since).Round(time.Second), task.Mode) + "\n")
+	}
+
+	sb.WriteString(fmt.Sprintf("\n%s %s (%d)\n", getStatusIcon("pending"), p.statusLabel("pending"), len(pending)))
+	for i, task := range pending {
+		sb.WriteString(p.msgf("queue.pending_item", i+1, task.ID, task.UserID, now.Sub(task.StartTime).Round(time.Second), task.Mode) + "\n")
+	}
+
+	p.replyLong(bot, msg, strings.TrimRight(sb.String(), "\n"))
+}
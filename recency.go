@@ -0,0 +1,87 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// recentHeader and recentFooter delimit the recent-entries section appended to logs
+const (
+	recentHeader = "=== MOST RECENT ENTRIES ==="
+	recentFooter = "=== END MOST RECENT ENTRIES ==="
+)
+
+// logTimestampPattern matches common log timestamps, e.g. "2024-05-01 12:00:00" or "2024-05-01T12:00:00.123Z"
+var logTimestampPattern = regexp.MustCompile(`\d{4}[-/]\d{2}[-/]\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?`)
+
+// parseLogTimestamp extracts the first timestamp on a line
+func parseLogTimestamp(line string) (time.Time, bool) {
+	m := logTimestampPattern.FindString(line)
+	if m == "" {
+		return time.Time{}, false
+	}
+	m = strings.ReplaceAll(m, "/", "-")
+	m = strings.Replace(m, "T", " ", 1)
+	t, err := time.Parse("2006-01-02 15:04:05.999999999", m)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// recentEntries returns the n most recent lines of a log in chronological order
+// Lines without a timestamp (e.g. stack frames) take the timestamp of the line above;
+// when fewer than two lines carry timestamps, the last n lines are used
+func recentEntries(logContent string, n int) string {
+	lines := strings.Split(strings.TrimRight(logContent, "\n"), "\n")
+	if n <= 0 || len(lines) <= n {
+		return ""
+	}
+
+	stamps := make([]time.Time, len(lines))
+	var last time.Time
+	found := 0
+	for i, line := range lines {
+		if t, ok := parseLogTimestamp(line); ok {
+			last = t
+			found++
+		}
+		stamps[i] = last
+	}
+	if found < 2 {
+		return strings.Join(lines[len(lines)-n:], "\n")
+	}
+
+	// Pick the n latest lines, then restore chronological (and original) order
+	order := make([]int, len(lines))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return stamps[order[a]].After(stamps[order[b]])
+	})
+	picked := order[:n]
+	sort.SliceStable(picked, func(a, b int) bool {
+		if !stamps[picked[a]].Equal(stamps[picked[b]]) {
+			return stamps[picked[a]].Before(stamps[picked[b]])
+		}
+		return picked[a] < picked[b]
+	})
+
+	recent := make([]string, len(picked))
+	for i, idx := range picked {
+		recent[i] = lines[idx]
+	}
+	return strings.Join(recent, "\n")
+}
+
+// emphasizeRecent appends a delimited copy of the most recent log entries
+func emphasizeRecent(logContent string, n int) string {
+	recent := recentEntries(logContent, n)
+	if recent == "" {
+		return logContent
+	}
+	return strings.TrimRight(logContent, "\n") + "\n\n" + recentHeader + "\n" + recent + "\n" + recentFooter
+}
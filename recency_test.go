@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRecentEntries(t *testing.T) {
+	tests := []struct {
+		name string
+		log  string
+		n    int
+		want string
+	}{
+		{"short log", "a\nb", 5, ""},
+		{"no timestamps", "a\nb\nc\nd\n", 2, "c\nd"},
+		{
+			"out of order timestamps",
+			"2024-05-01 12:00:03 ERROR late\n2024-05-01 12:00:01 INFO early\n2024-05-01T12:00:02Z WARN middle\n\tat frame",
+			3,
+			"2024-05-01T12:00:02Z WARN middle\n\tat frame\n2024-05-01 12:00:03 ERROR late",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := recentEntries(tt.log, tt.n); got != tt.want {
+				t.Errorf("recentEntries =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildPromptEmphasizesRecent(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.EmphasizeRecent = true
+	cfg.RecentEntryLines = 2
+	p, _ := newTestPlugin(cfg)
+
+	prompt := p.buildPrompt(&TaskStatus{config: p.cfg()}, "line 1\nline 2\nline 3\n")
+	want := "line 1\nline 2\nline 3\n\n" + recentHeader + "\nline 2\nline 3\n" + recentFooter
+	if !strings.HasPrefix(prompt, want) {
+		t.Errorf("prompt = %q, want the log followed by the delimited recent entries", prompt)
+	}
+	if !strings.Contains(prompt, "weigh them most") {
+		t.Errorf("prompt = %q, want the section explained to the model", prompt)
+	}
+
+	if prompt := p.buildPrompt(&TaskStatus{config: p.cfg()}, "line 1\nline 2"); strings.Contains(prompt, recentHeader) {
+		t.Errorf("prompt = %q, want no section for a log within the limit", prompt)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/DaikonSushi/bot-platform/pkg/pluginsdk"
+)
+
+// findReplyID returns the ID of the message a command replies to
+func findReplyID(msg *pluginsdk.Message) (string, bool) {
+	for _, seg := range msg.Segments {
+		if seg.Type == "reply" && seg.Data["id"] != "" {
+			return seg.Data["id"], true
+		}
+	}
+	return "", false
+}
+
+// getMsgResponse is the subset of the get_msg API response used here
+type getMsgResponse struct {
+	RawMessage string `json:"raw_message"`
+	Message    []struct {
+		Type string            `json:"type"`
+		Data map[string]string `json:"data"`
+	} `json:"message"`
+}
+
+// fetchRepliedText returns the text of a replied-to message with its formatting preserved
+func (p *LogAnalyzerPlugin) fetchRepliedText(messageID string) (string, error) {
+	data, err := p.bot.CallAPI("get_msg", map[string]string{"message_id": messageID})
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch replied message: %v", err)
+	}
+
+	// The response may or may not be wrapped in a {"data": ...} envelope
+	var info getMsgResponse
+	var envelope struct {
+		Data *getMsgResponse `json:"data"`
+	}
+	if err := json.Unmarshal(data, &envelope); err == nil && envelope.Data != nil {
+		info = *envelope.Data
+	} else if err := json.Unmarshal(data, &info); err != nil {
+		return "", fmt.Errorf("failed to decode replied message: %v", err)
+	}
+
+	// Prefer the text segments, which keep newlines and skip mentions and images
+	var sb strings.Builder
+	for _, seg := range info.Message {
+		if seg.Type == "text" {
+			sb.WriteString(seg.Data["text"])
+		}
+	}
+	if sb.Len() > 0 {
+		return sb.String(), nil
+	}
+	return info.RawMessage, nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/DaikonSushi/bot-platform/pkg/pluginsdk"
+)
+
+// splitMessage splits text into chunks of at most maxChars bytes, breaking at line ends
+// Lines longer than maxChars are split at rune boundaries
+func splitMessage(text string, maxChars int) []string {
+	if maxChars <= 0 || len(text) <= maxChars {
+		return []string{text}
+	}
+
+	var chunks []string
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			chunks = append(chunks, current.String())
+			current.Reset()
+		}
+	}
+
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if current.Len()+len(line) > maxChars {
+			flush()
+		}
+		for len(line) > maxChars {
+			cut := maxChars
+			for cut > 0 && !utf8.RuneStart(line[cut]) {
+				cut--
+			}
+			chunks = append(chunks, line[:cut])
+			line = line[cut:]
+		}
+		current.WriteString(line)
+	}
+	flush()
+
+	return chunks
+}
+
+// replyLong sends text as one or more messages bounded by MaxReplyChars
+// Follow-up parts are numbered so a dropped part is noticeable
+func (p *LogAnalyzerPlugin) replyLong(bot *pluginsdk.BotClient, msg *pluginsdk.Message, text string) {
+	chunks := splitMessage(text, p.cfg().MaxReplyChars)
+	for i, chunk := range chunks {
+		if len(chunks) > 1 && i > 0 {
+			chunk = fmt.Sprintf("(%d/%d)\n%s", i+1, len(chunks), chunk)
+		}
+		if _, err := bot.Reply(msg, pluginsdk.Text(chunk)); err != nil {
+			p.logf("warn", "Failed to send reply part %d/%d: %v", i+1, len(chunks), err)
+		}
+	}
+}
+
+// truncateUTF8 cuts s to at most maxBytes without splitting a multi-byte character
+func truncateUTF8(s string, maxBytes int) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+	for maxBytes > 0 && !utf8.RuneStart(s[maxBytes]) {
+		maxBytes--
+	}
+	return s[:maxBytes]
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/DaikonSushi/bot-platform/pkg/pluginsdk"
+)
+
+func TestSplitMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		maxChars int
+		want     []string
+	}{
+		{"fits", "a\nb\n", 10, []string{"a\nb\n"}},
+		{"disabled", "aaaa\nbbbb\n", 0, []string{"aaaa\nbbbb\n"}},
+		{"line ends", "aaa\nbbb\nccc\n", 8, []string{"aaa\nbbb\n", "ccc\n"}},
+		{"long line", "abcdefgh\n", 4, []string{"abcd", "efgh", "\n"}},
+		{"rune boundary", "ééé", 3, []string{"é", "é", "é"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitMessage(tt.text, tt.maxChars)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("splitMessage = %q, want %q", got, tt.want)
+			}
+			for _, chunk := range got {
+				if !utf8.ValidString(chunk) {
+					t.Errorf("chunk %q is not valid UTF-8", chunk)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleStatusSplitsLongListings(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxReplyChars = 200
+	cfg.StatusPageSize = 0
+	p, bot := newTestPlugin(cfg)
+	msg := &pluginsdk.Message{Type: "private", UserID: 1}
+
+	start := time.Now()
+	for i := 0; i < 30; i++ {
+		id := fmt.Sprintf("TASK%04d", i)
+		p.tasks[id] = &TaskStatus{config: p.cfg(), ID: id, UserID: 1, Status: "completed", StartTime: start.Add(time.Duration(i) * time.Second)}
+	}
+
+	p.handleStatus(p.bot, nil, msg)
+
+	sent := bot.sent()
+	if len(sent) < 2 {
+		t.Fatalf("got %d messages, want the listing split", len(sent))
+	}
+	var all strings.Builder
+	for i, m := range sent {
+		if i > 0 && !strings.HasPrefix(m.text, fmt.Sprintf("(%d/%d)\n", i+1, len(sent))) {
+			t.Errorf("part %d = %q, want a part number", i+1, m.text)
+		}
+		all.WriteString(m.text)
+	}
+	// Every task is listed once, most recent first
+	last := -1
+	for i := 29; i >= 0; i-- {
+		idx := strings.Index(all.String(), fmt.Sprintf("TASK%04d", i))
+		if idx < 0 || idx < last {
+			t.Fatalf("TASK%04d missing or out of order", i)
+		}
+		last = idx
+	}
+}
+
+func TestHandleStatusPages(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.StatusPageSize = 2
+	p, bot := newTestPlugin(cfg)
+	msg := &pluginsdk.Message{Type: "private", UserID: 1}
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		id := fmt.Sprintf("TASK%04d", i)
+		p.tasks[id] = &TaskStatus{config: p.cfg(), ID: id, UserID: 1, Status: "completed", StartTime: start.Add(time.Duration(i) * time.Second)}
+	}
+
+	p.handleStatus(p.bot, nil, msg)
+	p.handleStatus(p.bot, []string{"page", "2"}, msg)
+	p.handleStatus(p.bot, []string{"page", "3"}, msg)
+
+	sent := bot.sent()
+	if len(sent) != 3 {
+		t.Fatalf("got %d messages, want 3", len(sent))
+	}
+	if first := sent[0].text; !strings.Contains(first, "TASK0002") || !strings.Contains(first, "TASK0001") || strings.Contains(first, "TASK0000") ||
+		!strings.Contains(first, "Showing 1-2 of 3, use /analyzestatus page 2") {
+		t.Errorf("page 1 = %q, want the two most recent tasks and a pointer to page 2", first)
+	}
+	if second := sent[1].text; !strings.Contains(second, "TASK0000") || !strings.Contains(second, "Showing 3-3 of 3\n") {
+		t.Errorf("page 2 = %q, want the oldest task", second)
+	}
+	if !strings.Contains(sent[2].text, "Page 3 does not exist") {
+		t.Errorf("page 3 = %q, want an error", sent[2].text)
+	}
+}
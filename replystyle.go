@@ -0,0 +1,111 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/DaikonSushi/bot-platform/pkg/pluginsdk"
+)
+
+// Reply styles accepted by ReplyStyle
+const (
+	replyStylePlain    = "plain"
+	replyStyleMarkdown = "markdown"
+)
+
+// replyDivider separates the header and metadata from the body in plain replies
+const replyDivider = "━━━━━━━━━━━━━━━━━━━━\n"
+
+// listItemPattern matches Markdown list items, which stay outside fences even when indented
+var listItemPattern = regexp.MustCompile(`^([-*+]|\d+[.)]) `)
+
+// markdownReplies reports whether replies are rendered as Markdown
+func (p *LogAnalyzerPlugin) markdownReplies() bool {
+	return p.cfg().ReplyStyle == replyStyleMarkdown
+}
+
+// replyHeader renders a reply title: followed by a divider in plain style, bold in Markdown
+func (p *LogAnalyzerPlugin) replyHeader(title string) []pluginsdk.MessageSegment {
+	if p.markdownReplies() {
+		return []pluginsdk.MessageSegment{pluginsdk.Text("**" + title + "**\n\n")}
+	}
+	return []pluginsdk.MessageSegment{pluginsdk.Text(title + "\n"), pluginsdk.Text(replyDivider)}
+}
+
+// replyField renders one metadata line; Markdown style makes it a list item
+func (p *LogAnalyzerPlugin) replyField(line string) pluginsdk.MessageSegment {
+	if p.markdownReplies() {
+		return pluginsdk.Text("- " + line + "\n")
+	}
+	return pluginsdk.Text(line + "\n")
+}
+
+// replyFooter closes the metadata block before the body
+func (p *LogAnalyzerPlugin) replyFooter() pluginsdk.MessageSegment {
+	if p.markdownReplies() {
+		return pluginsdk.Text("\n")
+	}
+	return pluginsdk.Text(replyDivider + "\n")
+}
+
+// isLogSectionLine reports whether a result line is quoted log or code output:
+// stack frames, timestamped or syslog/access lines, or indented blocks
+func isLogSectionLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || listItemPattern.MatchString(trimmed) {
+		return false
+	}
+	if strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "    ") {
+		return true
+	}
+	// A timestamp at the start of the line, optionally bracketed
+	if loc := logTimestampPattern.FindStringIndex(trimmed); loc != nil && loc[0] <= 1 {
+		return true
+	}
+	return javaFramePattern.MatchString(line) || pythonFramePattern.MatchString(line) ||
+		syslogLinePattern.MatchString(trimmed) || accessLinePattern.MatchString(trimmed)
+}
+
+// fenceLogSections wraps runs of log or code lines in ``` fences for Markdown replies
+// Existing fenced blocks are copied unchanged
+func fenceLogSections(result string) string {
+	lines := strings.Split(result, "\n")
+	var out []string
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			end := i + 1
+			for end < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[end]), "```") {
+				end++
+			}
+			out = append(out, lines[i:min(end+1, len(lines))]...)
+			i = end
+			continue
+		}
+
+		if !isLogSectionLine(line) {
+			out = append(out, line)
+			continue
+		}
+
+		end := i + 1
+		for end < len(lines) && isLogSectionLine(lines[end]) {
+			end++
+		}
+		out = append(out, "```")
+		out = append(out, lines[i:end]...)
+		out = append(out, "```")
+		i = end - 1
+	}
+	return strings.Join(out, "\n")
+}
+
+// closeOpenFence terminates a ``` fence left open by truncating or splitting a Markdown reply
+func closeOpenFence(text string) string {
+	if strings.Count(text, "```")%2 == 1 {
+		return text + "\n```"
+	}
+	return text
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"sort"
+	"time"
+
+	"github.com/DaikonSushi/bot-platform/pkg/pluginsdk"
+)
+
+// handleRequeue handles the analyzerequeue admin command
+// Usage: /analyzerequeue --since <duration> [--cause timeout|connection]
+func (p *LogAnalyzerPlugin) handleRequeue(bot *pluginsdk.BotClient, args []string, msg *pluginsdk.Message) {
+	if !p.isAdmin(msg.UserID) {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.admin_only")))
+		return
+	}
+
+	var since time.Duration
+	cause := ""
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--since":
+			if i+1 >= len(args) {
+				p.replyRequeueUsage(bot, msg)
+				return
+			}
+			i++
+			d, err := time.ParseDuration(args[i])
+			if err != nil || d <= 0 {
+				bot.Reply(msg, pluginsdk.Text(p.msgf("err.invalid_duration", args[i])))
+				return
+			}
+			since = d
+		case "--cause":
+			if i+1 >= len(args) {
+				p.replyRequeueUsage(bot, msg)
+				return
+			}
+			i++
+			cause = args[i]
+			if cause != errorCategoryTimeout && cause != errorCategoryConnection {
+				bot.Reply(msg, pluginsdk.Text(p.msgf("err.unknown_cause", cause)))
+				return
+			}
+		default:
+			p.replyRequeueUsage(bot, msg)
+			return
+		}
+	}
+	if since == 0 {
+		p.replyRequeueUsage(bot, msg)
+		return
+	}
+
+	failed := p.failedTasksSince(time.Now().Add(-since), cause)
+	requeued := 0
+	for _, old := range failed {
+		if old.logContent == "" || old.msg == nil {
+			continue
+		}
+
+		task := p.createTask(old.msg, old.Options)
+		task.RetryOf = old.ID
+		task.InputTruncated = old.InputTruncated
+
+		p.taskMutex.Lock()
+		old.RequeuedAs = task.ID
+		p.taskMutex.Unlock()
+
+		p.bot.Reply(old.msg, pluginsdk.Text(p.msgf("requeue.task", old.ID, task.ID)))
+		p.startAnalysis(task, old.logContent, old.msg)
+		requeued++
+	}
+
+	bot.Reply(msg, pluginsdk.Text(p.msgf("requeue.done", requeued, len(failed), since)))
+}
+
+// failedTasksSince returns failed, not yet requeued tasks that ended after cutoff, oldest first
+// When cause is set, only tasks with that error category are returned
+func (p *LogAnalyzerPlugin) failedTasksSince(cutoff time.Time, cause string) []*TaskStatus {
+	p.taskMutex.RLock()
+	defer p.taskMutex.RUnlock()
+
+	var failed []*TaskStatus
+	for _, task := range p.tasks {
+		if task.Status != "failed" || task.RequeuedAs != "" || task.EndTime.Before(cutoff) {
+			continue
+		}
+		if cause != "" && task.ErrorCategory != cause {
+			continue
+		}
+		failed = append(failed, task)
+	}
+	sort.Slice(failed, func(i, j int) bool { return failed[i].EndTime.Before(failed[j].EndTime) })
+	return failed
+}
+
+// replyRequeueUsage shows analyzerequeue usage
+func (p *LogAnalyzerPlugin) replyRequeueUsage(bot *pluginsdk.BotClient, msg *pluginsdk.Message) {
+	bot.Reply(msg, pluginsdk.Text(p.msgf("usage.requeue")))
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFailedTasksSince(t *testing.T) {
+	p, _ := newTestPlugin(DefaultConfig())
+	now := time.Now()
+	for _, task := range []*TaskStatus{
+		{ID: "OLD", Status: "failed", ErrorCategory: errorCategoryTimeout, EndTime: now.Add(-2 * time.Hour)},
+		{ID: "TIMEOUT", Status: "failed", ErrorCategory: errorCategoryTimeout, EndTime: now.Add(-10 * time.Minute)},
+		{ID: "CONN", Status: "failed", ErrorCategory: errorCategoryConnection, EndTime: now.Add(-20 * time.Minute)},
+		{ID: "BACKEND", Status: "failed", ErrorCategory: errorCategoryBackend, EndTime: now.Add(-5 * time.Minute)},
+		{ID: "DONE", Status: "completed", EndTime: now.Add(-time.Minute)},
+		{ID: "RETRIED", Status: "failed", ErrorCategory: errorCategoryTimeout, EndTime: now.Add(-time.Minute), RequeuedAs: "NEW"},
+	} {
+		p.tasks[task.ID] = task
+	}
+
+	tests := []struct {
+		cause string
+		want  []string
+	}{
+		{"", []string{"CONN", "TIMEOUT", "BACKEND"}},
+		{errorCategoryTimeout, []string{"TIMEOUT"}},
+		{errorCategoryConnection, []string{"CONN"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, task := range p.failedTasksSince(now.Add(-time.Hour), tt.cause) {
+			got = append(got, task.ID)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("cause %q: got %v, want %v", tt.cause, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// severityPattern matches a "Severity: HIGH" style marker in the analysis result
+var severityPattern = regexp.MustCompile(`(?im)^[\s*#>\-]*(?:severity|严重程度|严重级别)\s*[:：]\s*\**\s*(critical|high|medium|low|info)\b`)
+
+// parseSeverity extracts a normalized severity level from the result, or "" if absent
+func parseSeverity(result string) string {
+	m := severityPattern.FindStringSubmatch(result)
+	if m == nil {
+		return ""
+	}
+	return normalizeSeverity(m[1])
+}
+
+// normalizeSeverity upper-cases a known severity level and drops unknown ones
+func normalizeSeverity(level string) string {
+	level = strings.ToUpper(strings.TrimSpace(level))
+	switch level {
+	case "CRITICAL", "HIGH", "MEDIUM", "LOW", "INFO":
+		return level
+	}
+	return ""
+}
+
+// redactHosts replaces internal hostnames and IPs in the result with "<host>"
+func (c *Config) redactHosts(result string) string {
+	if c.hostRedactor == nil {
+		return result
+	}
+	return c.hostRedactor.ReplaceAllString(result, "<host>")
+}
+
+// getSeverityIcon returns a color-coded emoji for a severity level
+func getSeverityIcon(level string) string {
+	switch level {
+	case "CRITICAL":
+		return "🚨"
+	case "HIGH":
+		return "🔴"
+	case "MEDIUM":
+		return "🟠"
+	case "LOW":
+		return "🟡"
+	case "INFO":
+		return "🔵"
+	default:
+		return "❓"
+	}
+}
+
+// privateIPPattern matches IPv4 addresses in private and loopback ranges
+const privateIPPattern = `\b(?:10(?:\.\d{1,3}){3}|127(?:\.\d{1,3}){3}|192\.168(?:\.\d{1,3}){2}|172\.(?:1[6-9]|2\d|3[01])(?:\.\d{1,3}){2})\b`
+
+// buildHostRedactor compiles the pattern used to redact internal hosts from results
+// A custom pattern replaces the default; the domain suffix is always added when set
+func buildHostRedactor(pattern, domainSuffix string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		pattern = privateIPPattern
+	}
+	if domainSuffix != "" {
+		suffix := "." + strings.TrimPrefix(domainSuffix, ".")
+		pattern += `|\b[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*` + regexp.QuoteMeta(suffix) + `\b`
+	}
+	return regexp.Compile(pattern)
+}
+
+// isDiffStart reports whether lines[i] begins a unified diff block
+func isDiffStart(lines []string, i int) bool {
+	line := lines[i]
+	if strings.HasPrefix(line, "diff --git ") || strings.HasPrefix(line, "@@ ") {
+		return true
+	}
+	return strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ")
+}
+
+// isDiffLine reports whether a line can continue a unified diff block
+func isDiffLine(line string) bool {
+	if line == "" {
+		return false
+	}
+	switch line[0] {
+	case '+', '-', ' ', '\\':
+		return true
+	}
+	return strings.HasPrefix(line, "@@") || strings.HasPrefix(line, "diff ") || strings.HasPrefix(line, "index ")
+}
+
+// highlightDiffs wraps unified diff blocks in ```diff fences so chat clients highlight them
+// Unlabeled fences that contain a diff get the diff language hint
+func highlightDiffs(result string) string {
+	lines := strings.Split(result, "\n")
+	var out []string
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		// Existing fenced block: copy it, labeling it as diff if it is one
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			end := i + 1
+			for end < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[end]), "```") {
+				end++
+			}
+			if strings.TrimSpace(line) == "```" && end > i+1 && isDiffStart(lines[i+1:end], 0) {
+				line = strings.Replace(line, "```", "```diff", 1)
+			}
+			out = append(out, line)
+			out = append(out, lines[i+1:min(end+1, len(lines))]...)
+			i = end
+			continue
+		}
+
+		if !isDiffStart(lines, i) {
+			out = append(out, line)
+			continue
+		}
+
+		end := i + 1
+		for end < len(lines) && isDiffLine(lines[end]) {
+			end++
+		}
+		out = append(out, "```diff")
+		out = append(out, lines[i:end]...)
+		out = append(out, "```")
+		i = end - 1
+	}
+
+	return strings.Join(out, "\n")
+}
+
+// ansiPattern matches ANSI CSI escape sequences such as color codes ("\x1b[31m")
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]`)
+
+// cleanOutput applies the configured clean-up to knot-cli output before it is stored or sent
+func (c *Config) cleanOutput(s string) string {
+	if c.StripANSI {
+		return stripANSI(s)
+	}
+	return s
+}
+
+// stripANSI removes ANSI CSI escape sequences from knot-cli output
+func stripANSI(s string) string {
+	if !strings.Contains(s, "\x1b[") {
+		return s
+	}
+	return ansiPattern.ReplaceAllString(s, "")
+}
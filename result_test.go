@@ -0,0 +1,109 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DaikonSushi/bot-platform/pkg/pluginsdk"
+)
+
+func TestParseSeverity(t *testing.T) {
+	tests := []struct {
+		result string
+		want   string
+	}{
+		{"Severity: HIGH\nThe pool is exhausted", "HIGH"},
+		{"## Analysis\n- **Severity:** critical", "CRITICAL"},
+		{"严重程度：中\n", ""},
+		{"严重程度：medium", "MEDIUM"},
+		{"> severity: low", "LOW"},
+		{"Severity: catastrophic", ""},
+		{"The severity of this is unclear", ""},
+	}
+	for _, tt := range tests {
+		if got := parseSeverity(tt.result); got != tt.want {
+			t.Errorf("parseSeverity(%q) = %q, want %q", tt.result, got, tt.want)
+		}
+	}
+}
+
+func TestSendResultSeverityBanner(t *testing.T) {
+	tests := []struct {
+		name   string
+		result string
+		want   string
+	}{
+		{"parsed severity", "Severity: High\nDisk is full", "🔴 Severity: HIGH\n✅ Analysis Completed"},
+		{"no severity", "Disk is full", "✅ Analysis Completed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.ShowSeverity = true
+			p, bot := newTestPlugin(cfg)
+
+			p.sendResult(&TaskStatus{config: p.cfg(), ID: "T1"}, "", tt.result, &pluginsdk.Message{Type: "private", UserID: 1})
+
+			sent := bot.sent()
+			if len(sent) != 1 || !strings.HasPrefix(sent[0].text, tt.want) {
+				t.Errorf("reply = %+v, want it to start with %q", sent, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactHosts(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.RedactHostsInResult = true
+	cfg.RedactDomainSuffix = "corp.example.com"
+	p, _ := newTestPlugin(cfg)
+
+	in := "db-01.corp.example.com (10.2.3.4) refused, retried 172.20.0.9 and 192.168.1.1; public 8.8.8.8 and example.com are fine"
+	want := "<host> (<host>) refused, retried <host> and <host>; public 8.8.8.8 and example.com are fine"
+	if got := p.cfg().redactHosts(in); got != want {
+		t.Errorf("redactHosts =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRedactHostsDisabled(t *testing.T) {
+	p, _ := newTestPlugin(DefaultConfig())
+	if got := p.cfg().redactHosts("10.0.0.1"); got != "10.0.0.1" {
+		t.Errorf("redactHosts without a redactor = %q", got)
+	}
+}
+
+func TestHighlightDiffs(t *testing.T) {
+	tests := []struct {
+		name   string
+		result string
+		want   string
+	}{
+		{
+			"bare diff",
+			"Suggested fix:\n--- a/pool.go\n+++ b/pool.go\n@@ -1,2 +1,2 @@\n-size := 10\n+size := 50\nRestart the service.",
+			"Suggested fix:\n```diff\n--- a/pool.go\n+++ b/pool.go\n@@ -1,2 +1,2 @@\n-size := 10\n+size := 50\n```\nRestart the service.",
+		},
+		{
+			"unlabeled fence",
+			"```\n@@ -1 +1 @@\n-a\n+b\n```",
+			"```diff\n@@ -1 +1 @@\n-a\n+b\n```",
+		},
+		{
+			"labeled fence is kept",
+			"```go\n--- x\n+++ y\n```",
+			"```go\n--- x\n+++ y\n```",
+		},
+		{
+			"markdown list is not a diff",
+			"Steps:\n- check the pool\n- restart",
+			"Steps:\n- check the pool\n- restart",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := highlightDiffs(tt.result); got != tt.want {
+				t.Errorf("highlightDiffs =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// redactedSecret replaces the sensitive part of a matched secret
+const redactedSecret = "***REDACTED***"
+
+// secretRule replaces matches of pattern; with keepPrefix, the first group (e.g. "password=") is kept
+type secretRule struct {
+	pattern    *regexp.Regexp
+	keepPrefix bool
+}
+
+// defaultSecretRules match common credentials found in pasted logs
+var defaultSecretRules = []secretRule{
+	{regexp.MustCompile(`(?i)(authorization\s*[:=]\s*["']?(?:bearer|basic|token)\s+)[^\s"',;]+`), true},
+	{regexp.MustCompile(`(?i)(\bbearer\s+)[A-Za-z0-9\-._~+/]{8,}=*`), true},
+	{regexp.MustCompile(`(?i)(\b(?:password|passwd|pwd|secret|token|api[_-]?key|access[_-]?key|secret[_-]?key|client[_-]?secret)["']?\s*[:=]\s*["']?)[^\s"'&,;]+`), true},
+	{regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`), false},
+	{regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]+`), false},
+	{regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`), false},
+}
+
+// buildSecretRules returns the default rules plus extra patterns, whose whole match is redacted
+func buildSecretRules(extra []string) ([]secretRule, error) {
+	rules := append([]secretRule(nil), defaultSecretRules...)
+	for _, pattern := range extra {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return defaultSecretRules, fmt.Errorf("invalid secret pattern %q: %v", pattern, err)
+		}
+		rules = append(rules, secretRule{pattern: re})
+	}
+	return rules, nil
+}
+
+// redactSecrets replaces credentials in s with ***REDACTED***
+func (c *Config) redactSecrets(s string) string {
+	for _, rule := range c.secretRules {
+		if rule.keepPrefix {
+			s = rule.pattern.ReplaceAllString(s, "${1}"+redactedSecret)
+		} else {
+			s = rule.pattern.ReplaceAllLiteralString(s, redactedSecret)
+		}
+	}
+	return s
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"time"
+
+	"github.com/DaikonSushi/bot-platform/pkg/pluginsdk"
+)
+
+// shutdownCancelWait bounds how long OnStop waits for cancelled tasks to wind down
+const shutdownCancelWait = 5 * time.Second
+
+// startAnalysis runs a task in the background, tracked so OnStop can drain it
+// Tasks created while the plugin is stopping are cancelled instead
+func (p *LogAnalyzerPlugin) startAnalysis(task *TaskStatus, logContent string, msg *pluginsdk.Message) {
+	if p.stopping.Load() {
+		p.finishTask(task, errTaskCancelled)
+		return
+	}
+	p.running.Add(1)
+	go func() {
+		defer p.running.Done()
+		p.runAnalysis(task, logContent, msg)
+	}()
+}
+
+// drainTasks waits up to ShutdownGraceSec for in-flight analyses, then cancels the rest
+func (p *LogAnalyzerPlugin) drainTasks() {
+	drained := make(chan struct{})
+	go func() {
+		p.running.Wait()
+		close(drained)
+	}()
+
+	grace := time.Duration(p.cfg().ShutdownGraceSec) * time.Second
+	if grace > 0 {
+		p.logf("info", "Waiting up to %s for in-flight analyses", grace)
+		select {
+		case <-drained:
+			return
+		case <-time.After(grace):
+		}
+	}
+
+	p.logf("warn", "Cancelling remaining analyses")
+	p.cancelTasks()
+	select {
+	case <-drained:
+	case <-time.After(shutdownCancelWait):
+		p.logf("warn", "Some analyses did not stop in time")
+	}
+}
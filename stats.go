@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DaikonSushi/bot-platform/pkg/pluginsdk"
+)
+
+// taskStats aggregates the task map for /analyzestats
+type taskStats struct {
+	byStatus map[string]int
+	avg      time.Duration
+	p95      time.Duration
+	samples  int
+	byMode   map[string]*ModeCounts
+}
+
+// collectTaskStats counts tasks by status and summarizes completed task durations
+func (p *LogAnalyzerPlugin) collectTaskStats() taskStats {
+	p.taskMutex.RLock()
+	defer p.taskMutex.RUnlock()
+
+	stats := taskStats{byStatus: make(map[string]int), byMode: make(map[string]*ModeCounts)}
+	var durations []time.Duration
+	for _, task := range p.tasks {
+		stats.byStatus[task.Status]++
+		stats.countMode(task)
+		if task.Status == "completed" && !task.EndTime.IsZero() {
+			durations = append(durations, task.EndTime.Sub(task.StartTime))
+		}
+	}
+
+	stats.samples = len(durations)
+	if len(durations) == 0 {
+		return stats
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	var total time.Duration
+	for _, d := range durations {
+		total += d
+	}
+	stats.avg = total / time.Duration(len(durations))
+	stats.p95 = durations[percentileIndex(len(durations), 0.95)]
+	return stats
+}
+
+// countMode adds a task to the per-mode breakdown; tasks from before modes were
+// recorded are left out
+func (s *taskStats) countMode(task *TaskStatus) {
+	if task.Mode == "" {
+		return
+	}
+	counts, ok := s.byMode[task.Mode]
+	if !ok {
+		counts = &ModeCounts{}
+		s.byMode[task.Mode] = counts
+	}
+	counts.Created++
+	switch task.Status {
+	case "completed":
+		counts.Completed++
+	case "failed":
+		counts.Failed++
+		if task.ErrorCategory == errorCategoryTimeout {
+			counts.TimedOut++
+		}
+	case "cancelled":
+		counts.Cancelled++
+	}
+}
+
+// percentileIndex returns the nearest-rank index of percentile q in a sorted slice of n values
+func percentileIndex(n int, q float64) int {
+	idx := int(float64(n)*q+0.999999) - 1
+	return max(0, min(idx, n-1))
+}
+
+// handleStats handles the analyzestats admin command
+func (p *LogAnalyzerPlugin) handleStats(bot *pluginsdk.BotClient, msg *pluginsdk.Message) {
+	if !p.isAdmin(msg.UserID) {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.admin_only")))
+		return
+	}
+
+	stats := p.collectTaskStats()
+
+	var sb strings.Builder
+	sb.WriteString(p.msgf("stats.title") + "\n")
+	sb.WriteString("━━━━━━━━━━━━━━━━━━━━\n")
+	for _, status := range []string{"pending", "running", "completed", "failed", "cancelled"} {
+		sb.WriteString(fmt.Sprintf("%s %s: %d\n", getStatusIcon(status), p.statusLabel(status), stats.byStatus[status]))
+	}
+	if stats.samples > 0 {
+		sb.WriteString(p.msgf("stats.avg", stats.avg.Round(time.Millisecond)) + "\n")
+		sb.WriteString(p.msgf("stats.p95", stats.p95.Round(time.Millisecond)) + "\n")
+	}
+	for _, mode := range []string{"direct", "proxy"} {
+		counts, ok := stats.byMode[mode]
+		if !ok {
+			continue
+		}
+		rate := 0.0
+		if done := counts.Completed + counts.Failed; done > 0 {
+			rate = float64(counts.Completed) * 100 / float64(done)
+		}
+		sb.WriteString(p.msgf("stats.mode", mode, counts.Created, counts.Completed, counts.Failed, counts.TimedOut, counts.Cancelled, rate) + "\n")
+	}
+	if cache := p.cfg().cache; cache != nil {
+		hits, misses, entries := cache.Stats()
+		rate := 0.0
+		if hits+misses > 0 {
+			rate = float64(hits) * 100 / float64(hits+misses)
+		}
+		sb.WriteString(p.msgf("stats.cache", hits, misses, rate, entries) + "\n")
+	}
+	sem := p.globalSemaphore()
+	sb.WriteString(p.msgf("stats.slots", len(sem), cap(sem)) + "\n")
+	if p.cfg().MaxConcurrentPerGroup > 0 {
+		busy := p.busyGroupSlots()
+		groups := make([]int64, 0, len(busy))
+		for groupID := range busy {
+			groups = append(groups, groupID)
+		}
+		sort.Slice(groups, func(i, j int) bool {
+			return busy[groups[i]] > busy[groups[j]] || (busy[groups[i]] == busy[groups[j]] && groups[i] < groups[j])
+		})
+		var parts []string
+		for _, groupID := range groups {
+			parts = append(parts, fmt.Sprintf("%d %d/%d", groupID, busy[groupID], p.cfg().MaxConcurrentPerGroup))
+		}
+		if len(parts) == 0 {
+			parts = append(parts, p.msgf("stats.none"))
+		}
+		sb.WriteString(p.msgf("stats.group_slots", strings.Join(parts, ", ")) + "\n")
+	}
+	sb.WriteString(p.msgf("stats.uptime", time.Since(p.startedAt).Round(time.Second)))
+
+	bot.Reply(msg, pluginsdk.Text(sb.String()))
+}
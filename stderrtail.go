@@ -0,0 +1,43 @@
+package main
+
+import "strings"
+
+// stderrTailLines is how many trailing knot-cli stderr lines are kept for failure messages
+const stderrTailLines = 20
+
+// lineRing keeps the last few lines written to it
+type lineRing struct {
+	lines []string
+	next  int
+	full  bool
+}
+
+// newLineRing creates a ring holding up to n lines
+func newLineRing(n int) *lineRing {
+	return &lineRing{lines: make([]string, n)}
+}
+
+// Add appends a line, dropping the oldest once the ring is full
+func (r *lineRing) Add(line string) {
+	r.lines[r.next] = line
+	r.next = (r.next + 1) % len(r.lines)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// String returns the kept lines, oldest first
+func (r *lineRing) String() string {
+	if !r.full {
+		return strings.Join(r.lines[:r.next], "\n")
+	}
+	return strings.Join(append(append([]string(nil), r.lines[r.next:]...), r.lines[:r.next]...), "\n")
+}
+
+// withStderrTail appends the stderr tail to a failure message, if there is one
+func withStderrTail(message string, tail *lineRing) string {
+	if s := strings.TrimSpace(tail.String()); s != "" {
+		return message + "\nstderr:\n" + s
+	}
+	return message
+}
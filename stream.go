@@ -0,0 +1,89 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DaikonSushi/bot-platform/pkg/pluginsdk"
+)
+
+// streamMaxChars bounds the partial output shown in a progress reply (the tail is kept)
+const streamMaxChars = 3000
+
+// chatStreamer posts partial analysis output as progress replies while a task runs
+// Posting is append-only: the SDK has no message editing, so each reply carries only
+// the output added since the previous one
+type chatStreamer struct {
+	mu           sync.Mutex
+	post         func(text string) error
+	now          func() time.Time
+	header       string // title line of each progress reply
+	interval     time.Duration
+	everyLines   int
+	pendingLines int
+	lastPost     time.Time
+	content      strings.Builder
+	posted       int
+}
+
+// newChatStreamer returns the progress streamer for a task
+// It returns nil when streaming is disabled, in which case the result is only delivered
+// when the task completes
+func (p *LogAnalyzerPlugin) newChatStreamer(task *TaskStatus, msg *pluginsdk.Message) *chatStreamer {
+	if !task.config.StreamToChat {
+		return nil
+	}
+
+	return &chatStreamer{
+		post: func(text string) error {
+			_, err := p.bot.Reply(msg, pluginsdk.Text(text))
+			return err
+		},
+		now:        time.Now,
+		header:     p.msgf("progress.stream", task.ID),
+		interval:   time.Duration(task.config.StreamPostIntervalSec) * time.Second,
+		everyLines: task.config.StreamEveryLines,
+		lastPost:   time.Now(),
+	}
+}
+
+// Append adds a line of output and posts a progress reply once the interval has passed
+// or everyLines new lines have accumulated
+// The reply is sent after s.mu is released so a slow chat never blocks the output reader
+func (s *chatStreamer) Append(line string) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.content.WriteString(line + "\n")
+	s.pendingLines++
+	var text string
+	if s.now().Sub(s.lastPost) >= s.interval || (s.everyLines > 0 && s.pendingLines >= s.everyLines) {
+		text = s.takeLocked()
+	}
+	s.mu.Unlock()
+
+	if text == "" {
+		return
+	}
+	if err := s.post(text); err != nil {
+		s.mu.Lock()
+		s.interval *= 2 // back off rather than retry a failing chat every line
+		s.mu.Unlock()
+	}
+}
+
+// takeLocked marks the output added since the last progress post as posted and returns
+// the reply text for it; the caller must hold s.mu
+func (s *chatStreamer) takeLocked() string {
+	content := s.content.String()[s.posted:]
+	s.posted = s.content.Len()
+	s.lastPost = s.now()
+	s.pendingLines = 0
+	if len(content) > streamMaxChars {
+		content = "...\n" + content[len(content)-streamMaxChars:]
+	}
+	return s.header + "\n━━━━━━━━━━━━━━━━━━━━\n" + content
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClock is a settable time source for interval tests
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) Now() time.Time          { return c.t }
+func (c *fakeClock) Advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestStreamer(clock *fakeClock, interval time.Duration, everyLines int, posts *[]string, fail bool) *chatStreamer {
+	return &chatStreamer{
+		post: func(text string) error {
+			*posts = append(*posts, text)
+			if fail {
+				return errors.New("send failed")
+			}
+			return nil
+		},
+		now:        clock.Now,
+		header:     "🔄 Task T1 progress",
+		interval:   interval,
+		everyLines: everyLines,
+		lastPost:   clock.Now(),
+	}
+}
+
+func TestChatStreamerPostInterval(t *testing.T) {
+	tests := []struct {
+		name       string
+		everyLines int
+		steps      []time.Duration // clock advance before each appended line
+		wantPosts  int
+	}{
+		{"within interval", 0, []time.Duration{time.Second, time.Second, time.Second}, 0},
+		{"interval elapsed", 0, []time.Duration{time.Second, 30 * time.Second}, 1},
+		{"each interval", 0, []time.Duration{30 * time.Second, time.Second, 30 * time.Second}, 2},
+		{"every lines", 2, []time.Duration{0, 0, 0, 0, 0}, 2},
+		{"every lines disabled", 0, []time.Duration{0, 0, 0, 0, 0}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{t: time.Unix(0, 0)}
+			var posts []string
+			s := newTestStreamer(clock, 30*time.Second, tt.everyLines, &posts, false)
+			for i, step := range tt.steps {
+				clock.Advance(step)
+				s.Append("line " + string(rune('a'+i)))
+			}
+			if len(posts) != tt.wantPosts {
+				t.Fatalf("got %d posts, want %d: %q", len(posts), tt.wantPosts, posts)
+			}
+		})
+	}
+}
+
+func TestChatStreamerPostsOnlyNewOutput(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	var posts []string
+	s := newTestStreamer(clock, time.Minute, 2, &posts, false)
+
+	for _, line := range []string{"one", "two", "three", "four"} {
+		s.Append(line)
+	}
+	if len(posts) != 2 {
+		t.Fatalf("got %d posts, want 2", len(posts))
+	}
+	if !strings.Contains(posts[0], "one\ntwo\n") || strings.Contains(posts[0], "three") {
+		t.Errorf("first post = %q", posts[0])
+	}
+	if !strings.Contains(posts[1], "three\nfour\n") || strings.Contains(posts[1], "two") {
+		t.Errorf("second post = %q", posts[1])
+	}
+}
+
+func TestChatStreamerBacksOffOnError(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	var posts []string
+	s := newTestStreamer(clock, 10*time.Second, 0, &posts, true)
+
+	clock.Advance(10 * time.Second)
+	s.Append("a")
+	if len(posts) != 1 {
+		t.Fatalf("got %d posts, want 1", len(posts))
+	}
+
+	// The failed post doubled the interval, so 10s later nothing is sent
+	clock.Advance(10 * time.Second)
+	s.Append("b")
+	if len(posts) != 1 {
+		t.Fatalf("posted again before the backed-off interval: %d posts", len(posts))
+	}
+	clock.Advance(10 * time.Second)
+	s.Append("c")
+	if len(posts) != 2 {
+		t.Fatalf("got %d posts after the backed-off interval, want 2", len(posts))
+	}
+}
+
+func TestChatStreamerNilIsNoop(t *testing.T) {
+	var s *chatStreamer
+	s.Append("ignored")
+}
+
+func TestChatStreamerPostsWithoutHoldingLock(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	var s *chatStreamer
+	s = &chatStreamer{
+		post: func(text string) error {
+			// A post that needs the lock would deadlock if Append still held it
+			if !s.mu.TryLock() {
+				t.Error("post called while holding s.mu")
+				return nil
+			}
+			s.mu.Unlock()
+			return nil
+		},
+		now:        clock.Now,
+		header:     "🔄 Task T1 progress",
+		interval:   time.Minute,
+		everyLines: 1,
+		lastPost:   clock.Now(),
+	}
+	s.Append("line")
+}
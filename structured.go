@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// jsonOutputArgs ask knot-cli for a JSON result (direct mode, OutputFormat "json")
+var jsonOutputArgs = []string{"--output-format", "json"}
+
+// structuredResult is the JSON analysis emitted by knot-cli in json output mode
+type structuredResult struct {
+	Summary      string `json:"summary"`
+	RootCause    string `json:"root_cause"`
+	SuggestedFix string `json:"suggested_fix"`
+	Severity     string `json:"severity"`
+	RequestID    string `json:"request_id"`
+}
+
+// parseStructuredResult decodes a JSON result, tolerating text around the object
+// (e.g. stderr lines collected with the output); ok is false when nothing usable is found
+func parseStructuredResult(output string) (*structuredResult, bool) {
+	start := strings.Index(output, "{")
+	end := strings.LastIndex(output, "}")
+	if start < 0 || end < start {
+		return nil, false
+	}
+
+	var r structuredResult
+	if err := json.Unmarshal([]byte(output[start:end+1]), &r); err != nil {
+		return nil, false
+	}
+	if r.Summary == "" && r.RootCause == "" && r.SuggestedFix == "" {
+		return nil, false
+	}
+	return &r, true
+}
+
+// render formats the structured result for chat, with section titles from msgf
+func (r *structuredResult) render(msgf func(key string, args ...any) string) string {
+	var sections []string
+	add := func(title, body string) {
+		if body = strings.TrimSpace(body); body != "" {
+			sections = append(sections, title+"\n"+body)
+		}
+	}
+	add(msgf("structured.summary"), r.Summary)
+	add(msgf("structured.root_cause"), r.RootCause)
+	add(msgf("structured.fix"), r.SuggestedFix)
+	return strings.Join(sections, "\n\n")
+}
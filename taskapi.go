@@ -0,0 +1,75 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// registerTaskAPI adds the read-only task endpoints to the metrics server
+// GET /tasks/{id} returns the task as JSON, GET /tasks/{id}/output streams its result file
+func (p *LogAnalyzerPlugin) registerTaskAPI(mux *http.ServeMux) {
+	mux.HandleFunc("GET /tasks/{id}", p.requireAPIToken(p.handleTaskAPI))
+	mux.HandleFunc("GET /tasks/{id}/output", p.requireAPIToken(p.handleTaskOutputAPI))
+}
+
+// requireAPIToken rejects requests without "Authorization: Bearer <APIToken>"
+func (p *LogAnalyzerPlugin) requireAPIToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(p.cfg().APIToken)) != 1 {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// lookupTaskCopy returns a snapshot of a task for the HTTP API
+func (p *LogAnalyzerPlugin) lookupTaskCopy(id string) (TaskStatus, bool) {
+	p.taskMutex.RLock()
+	defer p.taskMutex.RUnlock()
+	task, ok := p.tasks[strings.ToUpper(id)]
+	if !ok {
+		return TaskStatus{}, false
+	}
+	return *task, true
+}
+
+// handleTaskAPI serves GET /tasks/{id}
+func (p *LogAnalyzerPlugin) handleTaskAPI(w http.ResponseWriter, r *http.Request) {
+	task, ok := p.lookupTaskCopy(r.PathValue("id"))
+	if !ok {
+		http.Error(w, "task not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(task)
+}
+
+// handleTaskOutputAPI serves GET /tasks/{id}/output
+// The host-redacted copy is preferred when one was written
+func (p *LogAnalyzerPlugin) handleTaskOutputAPI(w http.ResponseWriter, r *http.Request) {
+	task, ok := p.lookupTaskCopy(r.PathValue("id"))
+	if !ok {
+		http.Error(w, "task not found", http.StatusNotFound)
+		return
+	}
+
+	paths := p.taskOutputFiles(&task)
+	f, err := os.Open(paths[1])
+	if err != nil {
+		f, err = os.Open(paths[0])
+	}
+	if err != nil {
+		http.Error(w, "output not found", http.StatusNotFound)
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	io.Copy(w, f)
+}
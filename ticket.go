@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// defaultTicketWebhookTemplate is the request body posted to the ticket webhook
+// Placeholders are replaced with JSON-encoded strings
+const defaultTicketWebhookTemplate = `{"ticket_id": {ticket}, "task_id": {task_id}, "body": {comment}}`
+
+// postTicketComment posts the analysis result as a comment on the referenced ticket
+// It is best-effort: failures are logged and never reach the user
+func (p *LogAnalyzerPlugin) postTicketComment(task *TaskStatus, result string) {
+	ticketID := task.Options.TicketID
+	webhookURL := strings.ReplaceAll(task.config.TicketWebhook, "{ticket}", url.PathEscape(ticketID))
+
+	comment := fmt.Sprintf("Log analysis result (task %s, duration %s):\n\n%s", task.ID, task.Duration, result)
+
+	tmpl := task.config.TicketWebhookTemplate
+	if tmpl == "" {
+		tmpl = defaultTicketWebhookTemplate
+	}
+	body := strings.NewReplacer(
+		"{ticket}", jsonString(ticketID),
+		"{task_id}", jsonString(task.ID),
+		"{comment}", jsonString(comment),
+	).Replace(tmpl)
+
+	resp, err := task.config.httpClient.Post(webhookURL, "application/json", bytes.NewBufferString(body))
+	if err != nil {
+		p.taskLogf("warn", task.ID, "Failed to post result to ticket %s: %v", ticketID, err)
+		return
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		p.taskLogf("warn", task.ID, "Ticket webhook returned %s for ticket %s", resp.Status, ticketID)
+		return
+	}
+	p.taskLogf("info", task.ID, "Posted result to ticket %s", ticketID)
+}
+
+// jsonString encodes s as a JSON string literal
+func jsonString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPostTicketComment(t *testing.T) {
+	type request struct {
+		path string
+		body map[string]string
+	}
+	got := make(chan request, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]string
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("webhook body is not JSON: %v\n%s", err, data)
+		}
+		got <- request{path: r.URL.Path, body: body}
+	}))
+	defer srv.Close()
+
+	cfg := DefaultConfig()
+	cfg.TicketWebhook = srv.URL + "/issues/{ticket}/comments"
+	p, _ := newTestPlugin(cfg)
+
+	task := &TaskStatus{config: p.cfg(), ID: "ABC123", Duration: "1.5s", Options: AnalyzeOptions{TicketID: "OPS-42"}}
+	p.postTicketComment(task, "root cause: \"nil\" map")
+
+	req := <-got
+	if req.path != "/issues/OPS-42/comments" {
+		t.Errorf("webhook path = %q", req.path)
+	}
+	if req.body["ticket_id"] != "OPS-42" || req.body["task_id"] != "ABC123" {
+		t.Errorf("webhook body = %v", req.body)
+	}
+	if !strings.Contains(req.body["body"], `root cause: "nil" map`) {
+		t.Errorf("comment = %q, want it to contain the result", req.body["body"])
+	}
+}
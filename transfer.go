@@ -0,0 +1,79 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/DaikonSushi/bot-platform/pkg/pluginsdk"
+)
+
+// handleTransfer handles the analyzetransfer command
+// The task owner or an admin can hand a task over to another user, e.g. at shift change
+func (p *LogAnalyzerPlugin) handleTransfer(bot *pluginsdk.BotClient, args []string, msg *pluginsdk.Message) {
+	if len(args) != 2 {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("usage.transfer")))
+		return
+	}
+
+	taskID := strings.ToUpper(args[0])
+	newOwner, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil || newOwner <= 0 {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.invalid_user_id", args[1])))
+		return
+	}
+
+	p.taskMutex.Lock()
+	task, exists := p.tasks[taskID]
+	if !exists {
+		p.taskMutex.Unlock()
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.task_not_found", taskID)))
+		return
+	}
+	if task.UserID != msg.UserID && !p.isAdmin(msg.UserID) {
+		p.taskMutex.Unlock()
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.transfer_not_owner")))
+		return
+	}
+	if task.UserID == newOwner {
+		p.taskMutex.Unlock()
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.transfer_same_owner", taskID, newOwner)))
+		return
+	}
+
+	previousOwner := task.UserID
+	task.UserID = newOwner
+	task.TransferredFrom = previousOwner
+
+	// Redirect pending delivery: group results stay in the group, private results go to the new owner
+	if task.msg != nil {
+		target := *task.msg
+		target.UserID = newOwner
+		task.msg = &target
+	}
+	status := p.statusLabel(task.Status)
+	p.taskMutex.Unlock()
+	p.schedulePersist()
+
+	p.taskLogf("info", taskID, "Transferred from user %d to user %d by user %d", previousOwner, newOwner, msg.UserID)
+
+	if msg.Type == "group" {
+		bot.Reply(msg,
+			pluginsdk.Text(p.msgf("transfer.to", taskID, status)),
+			pluginsdk.At(newOwner),
+		)
+		return
+	}
+	bot.Reply(msg, pluginsdk.Text(p.msgf("transfer.to_user", taskID, status, newOwner)))
+	bot.SendPrivateMessage(newOwner, pluginsdk.Text(p.msgf("transfer.received", taskID, status, msg.UserID)))
+}
+
+// deliveryTarget returns the message results for a task should be delivered to
+// It follows ownership transfers, falling back to the original message
+func (p *LogAnalyzerPlugin) deliveryTarget(task *TaskStatus, msg *pluginsdk.Message) *pluginsdk.Message {
+	p.taskMutex.RLock()
+	defer p.taskMutex.RUnlock()
+	if task.msg != nil {
+		return task.msg
+	}
+	return msg
+}
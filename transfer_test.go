@@ -0,0 +1,44 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DaikonSushi/bot-platform/pkg/pluginsdk"
+)
+
+func TestTransferRedirectsResultDelivery(t *testing.T) {
+	p, bot := newTestPlugin(DefaultConfig())
+	ownerMsg := &pluginsdk.Message{Type: "private", UserID: 1}
+	task := &TaskStatus{config: p.cfg(), ID: "T1", Status: "running", StartTime: time.Now(), UserID: 1, msg: ownerMsg}
+	p.tasks[task.ID] = task
+
+	p.handleTransfer(p.bot, []string{"t1", "2"}, ownerMsg)
+	if task.UserID != 2 || task.TransferredFrom != 1 {
+		t.Fatalf("task owner = %d (from %d), want 2 (from 1)", task.UserID, task.TransferredFrom)
+	}
+
+	p.completeTaskWithResult(task, "", "Root cause: disk full", 0, ownerMsg)
+
+	var delivered []int64
+	for _, m := range bot.sent() {
+		if strings.Contains(m.text, "Root cause: disk full") {
+			delivered = append(delivered, m.userID)
+		}
+	}
+	if len(delivered) != 1 || delivered[0] != 2 {
+		t.Errorf("result delivered to %v, want only the new owner 2", delivered)
+	}
+}
+
+func TestTransferRequiresOwnerOrAdmin(t *testing.T) {
+	p, _ := newTestPlugin(DefaultConfig())
+	task := &TaskStatus{config: p.cfg(), ID: "T1", Status: "running", UserID: 1}
+	p.tasks[task.ID] = task
+
+	p.handleTransfer(p.bot, []string{"T1", "3"}, &pluginsdk.Message{Type: "private", UserID: 2})
+	if task.UserID != 1 {
+		t.Errorf("a non-owner transferred the task to %d", task.UserID)
+	}
+}
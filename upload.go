@@ -0,0 +1,272 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DaikonSushi/bot-platform/pkg/pluginsdk"
+)
+
+// quietWindow is a daily time window in minutes since midnight, wrapping past midnight when end < start
+type quietWindow struct {
+	start int
+	end   int
+}
+
+// parseQuietHours parses a window such as "22:00-07:00"
+func parseQuietHours(spec string) (*quietWindow, error) {
+	startStr, endStr, ok := strings.Cut(spec, "-")
+	if !ok {
+		return nil, fmt.Errorf("invalid quiet hours %q, expected HH:MM-HH:MM", spec)
+	}
+	start, err := parseClock(strings.TrimSpace(startStr))
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseClock(strings.TrimSpace(endStr))
+	if err != nil {
+		return nil, err
+	}
+	if start == end {
+		return nil, fmt.Errorf("invalid quiet hours %q, window is empty", spec)
+	}
+	return &quietWindow{start: start, end: end}, nil
+}
+
+// parseClock parses "HH:MM" into minutes since midnight
+func parseClock(s string) (int, error) {
+	hourStr, minStr, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", s)
+	}
+	hour, err := strconv.Atoi(hourStr)
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, fmt.Errorf("invalid hour in %q", s)
+	}
+	minute, err := strconv.Atoi(minStr)
+	if err != nil || minute < 0 || minute > 59 {
+		return 0, fmt.Errorf("invalid minute in %q", s)
+	}
+	return hour*60 + minute, nil
+}
+
+// contains reports whether t falls inside the window
+func (w *quietWindow) contains(t time.Time) bool {
+	m := t.Hour()*60 + t.Minute()
+	if w.start < w.end {
+		return m >= w.start && m < w.end
+	}
+	return m >= w.start || m < w.end
+}
+
+// startLabel formats the window start as HH:MM
+func (w *quietWindow) startLabel() string {
+	return fmt.Sprintf("%02d:%02d", w.start/60, w.start%60)
+}
+
+// deferredUpload is a result file waiting for the quiet-hours window
+type deferredUpload struct {
+	path    string
+	name    string
+	groupID int64
+	userID  int64
+}
+
+// uploadQueue holds deferred uploads keyed by task ID
+type uploadQueue struct {
+	mu      sync.Mutex
+	pending map[string][]deferredUpload
+}
+
+func newUploadQueue() *uploadQueue {
+	return &uploadQueue{pending: make(map[string][]deferredUpload)}
+}
+
+// add queues uploads for later delivery
+func (q *uploadQueue) add(taskID string, uploads ...deferredUpload) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending[taskID] = append(q.pending[taskID], uploads...)
+}
+
+// take removes and returns the queued uploads for a task
+func (q *uploadQueue) take(taskID string) ([]deferredUpload, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	uploads, ok := q.pending[taskID]
+	if ok {
+		delete(q.pending, taskID)
+	}
+	return uploads, ok
+}
+
+// drain removes and returns all queued uploads
+func (q *uploadQueue) drain() map[string][]deferredUpload {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	pending := q.pending
+	q.pending = make(map[string][]deferredUpload)
+	return pending
+}
+
+// shouldDeferUpload reports whether a large result upload should wait for quiet hours
+func (c *Config) shouldDeferUpload(now time.Time) bool {
+	return c.DeferLargeUploads && c.quietHours != nil && !c.quietHours.contains(now)
+}
+
+// uploadResultFile sends a result file to the chat the upload targets
+func (p *LogAnalyzerPlugin) uploadResultFile(upload deferredUpload) error {
+	if upload.groupID > 0 {
+		return p.bot.UploadGroupFile(upload.groupID, upload.path, upload.name, "/")
+	}
+	return p.bot.UploadPrivateFile(upload.userID, upload.path, upload.name)
+}
+
+// runUploadScheduler sends deferred uploads once the quiet-hours window opens
+func (p *LogAnalyzerPlugin) runUploadScheduler() {
+	ticks, stop := p.newTicker(time.Minute)
+	defer stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticks:
+			p.sendDeferredUploads(p.now())
+		}
+	}
+}
+
+// sendDeferredUploads sends every queued upload when now falls inside the quiet-hours window
+func (p *LogAnalyzerPlugin) sendDeferredUploads(now time.Time) {
+	// Removing quiet hours by a reload sends whatever is still waiting
+	if window := p.cfg().quietHours; window != nil && !window.contains(now) {
+		return
+	}
+	for taskID, uploads := range p.uploads.drain() {
+		for _, upload := range uploads {
+			if err := p.uploadResultFile(upload); err != nil {
+				p.taskLogf("warn", taskID, "Deferred upload failed: %v", err)
+			}
+		}
+	}
+}
+
+// handleResult handles the analyzeresult command, uploading a deferred result immediately
+// or re-sending the result file of a completed task
+func (p *LogAnalyzerPlugin) handleResult(bot *pluginsdk.BotClient, args []string, msg *pluginsdk.Message) {
+	if len(args) != 1 {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("usage.result")))
+		return
+	}
+
+	taskID := strings.ToUpper(args[0])
+	p.taskMutex.RLock()
+	task, exists := p.tasks[taskID]
+	allowed := exists && (task.UserID == msg.UserID || p.isAdmin(msg.UserID))
+	resultPath := ""
+	if exists {
+		resultPath = task.resultPath
+	}
+	p.taskMutex.RUnlock()
+
+	if !exists {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.task_not_found", taskID)))
+		return
+	}
+	if !allowed {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.fetch_not_owner")))
+		return
+	}
+
+	uploads, deferred := p.uploads.take(taskID)
+	if !deferred {
+		// Nothing deferred: re-send the result file, e.g. after a delivery timeout
+		if resultPath == "" {
+			bot.Reply(msg, pluginsdk.Text(p.msgf("err.no_result_file", taskID)))
+			return
+		}
+		uploads = []deferredUpload{{
+			path:    resultPath,
+			name:    fmt.Sprintf("analysis_%s.txt", taskID),
+			groupID: msg.GroupID,
+			userID:  msg.UserID,
+		}}
+	}
+	for i, upload := range uploads {
+		if err := p.uploadResultFile(upload); err != nil {
+			if deferred {
+				p.uploads.add(taskID, uploads[i:]...)
+			}
+			bot.Reply(msg, pluginsdk.Text(p.msgf("err.upload_failed", err)))
+			return
+		}
+	}
+}
+
+// handleGet handles the analyzeget command
+// It re-uploads a completed task's full output file, also after a restart, to the chat
+// the command was sent from; files removed by the janitor are reported as expired
+func (p *LogAnalyzerPlugin) handleGet(bot *pluginsdk.BotClient, args []string, msg *pluginsdk.Message) {
+	if len(args) != 1 {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("usage.get")))
+		return
+	}
+
+	taskID := strings.ToUpper(args[0])
+	p.taskMutex.RLock()
+	task, exists := p.tasks[taskID]
+	var status, resultPath string
+	var candidates []string
+	allowed := exists && (task.UserID == msg.UserID || p.isAdmin(msg.UserID))
+	if exists {
+		status, resultPath = task.Status, task.resultPath
+		candidates = p.taskOutputFiles(task)
+	}
+	p.taskMutex.RUnlock()
+
+	if !exists {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.task_not_found", taskID)))
+		return
+	}
+	if !allowed {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.fetch_not_owner")))
+		return
+	}
+	if status != "completed" {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.get_not_completed", taskID, p.statusLabel(status))))
+		return
+	}
+
+	// The delivered file, else the redacted or plain output left on disk
+	if resultPath == "" {
+		candidates = []string{candidates[1], candidates[0]}
+	} else {
+		candidates = []string{resultPath}
+	}
+	path := ""
+	for _, c := range candidates {
+		if _, err := os.Stat(c); err == nil {
+			path = c
+			break
+		}
+	}
+	if path == "" {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.output_expired", taskID)))
+		return
+	}
+
+	upload := deferredUpload{
+		path:    path,
+		name:    fmt.Sprintf("analysis_%s.txt", taskID),
+		groupID: msg.GroupID,
+		userID:  msg.UserID,
+	}
+	if err := p.uploadResultFile(upload); err != nil {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.upload_failed", err)))
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DaikonSushi/bot-platform/pkg/pluginsdk"
+)
+
+func TestParseQuietHours(t *testing.T) {
+	tests := []struct {
+		spec    string
+		wantErr bool
+		inside  []string
+		outside []string
+	}{
+		{spec: "01:00-05:30", inside: []string{"01:00", "03:00", "05:29"}, outside: []string{"00:59", "05:30", "23:00"}},
+		{spec: "22:00-07:00", inside: []string{"22:00", "23:59", "00:00", "06:59"}, outside: []string{"07:00", "12:00", "21:59"}},
+		{spec: " 9:05 - 9:10 ", inside: []string{"09:05"}, outside: []string{"09:10"}},
+		{spec: "22:00", wantErr: true},
+		{spec: "22:00-22:00", wantErr: true},
+		{spec: "24:00-07:00", wantErr: true},
+		{spec: "22:60-07:00", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			w, err := parseQuietHours(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseQuietHours error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for _, clock := range tt.inside {
+				if !w.contains(clockTime(t, clock)) {
+					t.Errorf("%s should be inside %s", clock, tt.spec)
+				}
+			}
+			for _, clock := range tt.outside {
+				if w.contains(clockTime(t, clock)) {
+					t.Errorf("%s should be outside %s", clock, tt.spec)
+				}
+			}
+		})
+	}
+}
+
+// clockTime returns today's time at an HH:MM clock reading
+func clockTime(t *testing.T, clock string) time.Time {
+	t.Helper()
+	c, err := time.Parse("15:04", clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	y, m, d := time.Now().Date()
+	return time.Date(y, m, d, c.Hour(), c.Minute(), 0, 0, time.Local)
+}
+
+func TestLargeUploadDeferredUntilQuietHours(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.DeferLargeUploads = true
+	// A one-hour window starting two hours from now
+	now := time.Now()
+	start := now.Add(2 * time.Hour)
+	cfg.QuietHours = start.Format("15:04") + "-" + start.Add(time.Hour).Format("15:04")
+	p, bot := newTestPlugin(cfg)
+
+	msg := &pluginsdk.Message{Type: "group", GroupID: 100, UserID: 1}
+	p.sendResult(&TaskStatus{config: p.cfg(), ID: "T1"}, "/shared/analysis_T1.txt", strings.Repeat("x", 5000), msg)
+
+	if uploads := bot.uploaded(); len(uploads) != 0 {
+		t.Fatalf("uploaded %+v outside quiet hours", uploads)
+	}
+	if sent := bot.sent(); len(sent) != 1 || !strings.Contains(sent[0].text, "will be uploaded at "+p.cfg().quietHours.startLabel()) {
+		t.Errorf("reply = %+v, want the deferred upload notice", sent)
+	}
+
+	p.sendDeferredUploads(now)
+	if uploads := bot.uploaded(); len(uploads) != 0 {
+		t.Fatalf("uploaded %+v before the window opened", uploads)
+	}
+
+	p.sendDeferredUploads(now.Add(2*time.Hour + time.Minute))
+	uploads := bot.uploaded()
+	if len(uploads) != 1 || uploads[0].groupID != 100 || uploads[0].name != "analysis_T1.txt" {
+		t.Errorf("uploads = %+v, want analysis_T1.txt in group 100", uploads)
+	}
+
+	// Nothing is left to send on the next tick
+	p.sendDeferredUploads(now.Add(2*time.Hour + 2*time.Minute))
+	if n := len(bot.uploaded()); n != 1 {
+		t.Errorf("%d uploads after the queue drained, want 1", n)
+	}
+}
+
+func TestHandleResultUploadsDeferredFileNow(t *testing.T) {
+	p, bot := newTestPlugin(DefaultConfig())
+	p.tasks["T1"] = &TaskStatus{config: p.cfg(), ID: "T1", UserID: 1}
+	p.uploads.add("T1", deferredUpload{path: "/shared/analysis_T1.txt", name: "analysis_T1.txt", userID: 1})
+
+	p.handleResult(p.bot, []string{"t1"}, &pluginsdk.Message{Type: "private", UserID: 1})
+
+	if uploads := bot.uploaded(); len(uploads) != 1 || uploads[0].userID != 1 {
+		t.Errorf("uploads = %+v, want the deferred file sent to user 1", uploads)
+	}
+	if _, ok := p.uploads.take("T1"); ok {
+		t.Error("upload is still queued after /analyzeresult")
+	}
+}
+
+func TestDeferralEnabledByReload(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2026, time.March, 2, 12, 0, 0, 0, time.Local)}
+	ticks := make(chan time.Time)
+	cfg := DefaultConfig()
+	cfg.AdminUserIDs = []int64{1}
+	p, bot := newTestPlugin(cfg)
+	p.now = clock.Now
+	p.newTicker = func(time.Duration) (<-chan time.Time, func()) { return ticks, func() {} }
+	go p.runUploadScheduler()
+	defer close(p.done)
+
+	// Started without deferral; the reload turns it on
+	t.Setenv("LOGANALYZER_DEFER_LARGE_UPLOADS", "true")
+	t.Setenv("LOGANALYZER_QUIET_HOURS", "14:00-15:00")
+	msg := &pluginsdk.Message{Type: "private", UserID: 1}
+	p.handleReload(p.bot, msg)
+	if !p.cfg().DeferLargeUploads || p.cfg().quietHours == nil {
+		t.Fatal("reload did not enable deferred uploads")
+	}
+
+	p.sendResult(&TaskStatus{config: p.cfg(), ID: "T1"}, "/shared/analysis_T1.txt", strings.Repeat("x", 5000), msg)
+	if uploads := bot.uploaded(); len(uploads) != 0 {
+		t.Fatalf("uploaded %+v outside quiet hours", uploads)
+	}
+
+	// The scheduler running since startup sends it once the window opens
+	clock.Advance(2 * time.Hour)
+	ticks <- time.Time{}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(bot.uploaded()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("deferred upload was never sent")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
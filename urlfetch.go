@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// logFetchTimeout bounds fetching a log URL, which happens before /analyze replies
+const logFetchTimeout = 15 * time.Second
+
+// fetchableContentTypes are the response types accepted when fetching a log URL
+// Anything else (HTML pages, images, archives) is rejected before reading the body
+var fetchableContentTypes = []string{"text/", "application/json", "application/x-ndjson", "application/octet-stream"}
+
+// isLogURL reports whether an /analyze argument is an http(s) URL to fetch
+func isLogURL(arg string) bool {
+	u, err := url.Parse(arg)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// fetchHostAllowed reports whether a host may be fetched from
+// Entries also match their subdomains; an empty FetchAllowedHosts allows no host, so the
+// plugin cannot be pointed at internal addresses unless an admin opts in
+func (p *LogAnalyzerPlugin) fetchHostAllowed(host string) bool {
+	host = strings.ToLower(host)
+	for _, allowed := range p.cfg().FetchAllowedHosts {
+		allowed = strings.ToLower(allowed)
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchLogURL downloads a log from a URL, capped at MaxAttachmentBytes and logFetchTimeout
+// It uses its own client rather than the backend one, whose timeout is sized for analyses
+// Redirects are checked against the host allowlist as well
+func (p *LogAnalyzerPlugin) fetchLogURL(ctx context.Context, rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %v", err)
+	}
+	if len(p.cfg().FetchAllowedHosts) == 0 {
+		return "", fmt.Errorf("fetching URLs is not configured (LOGANALYZER_FETCH_ALLOWED_HOSTS not set)")
+	}
+	if !p.fetchHostAllowed(u.Hostname()) {
+		return "", fmt.Errorf("host %s is not in the allowed list", u.Hostname())
+	}
+
+	client := &http.Client{
+		Timeout: logFetchTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("too many redirects")
+			}
+			if !p.fetchHostAllowed(req.URL.Hostname()) {
+				return fmt.Errorf("redirect to host %s is not allowed", req.URL.Hostname())
+			}
+			return nil
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, logFetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %v", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch log: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch log: %s", resp.Status)
+	}
+
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		mediaType, _, _ := mime.ParseMediaType(ct)
+		if !fetchableContentType(mediaType) {
+			return "", fmt.Errorf("unsupported content type %s", mediaType)
+		}
+	}
+
+	limit := p.cfg().MaxAttachmentBytes
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read log: %v", err)
+	}
+	if int64(len(data)) > limit {
+		return "", fmt.Errorf("log is larger than %d bytes", limit)
+	}
+	if isBinary(data) {
+		return "", fmt.Errorf("content appears to be binary")
+	}
+	return string(data), nil
+}
+
+// fetchableContentType reports whether a media type is accepted for log URLs
+func fetchableContentType(mediaType string) bool {
+	if mediaType == "text/html" {
+		return false
+	}
+	for _, prefix := range fetchableContentTypes {
+		if strings.HasPrefix(mediaType, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFetchLogURLAllowedHosts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("ERROR disk full\n"))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		allowed []string
+		wantErr string
+	}{
+		{"empty list denies all", nil, "not configured"},
+		{"host not listed", []string{"ci.example.com"}, "not in the allowed list"},
+		{"host listed", []string{"127.0.0.1"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.FetchAllowedHosts = tt.allowed
+			p, _ := newTestPlugin(cfg)
+
+			content, err := p.fetchLogURL(context.Background(), srv.URL+"/build.log")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("fetchLogURL = %q, %v; want error containing %q", content, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || content != "ERROR disk full\n" {
+				t.Errorf("fetchLogURL = %q, %v; want the log", content, err)
+			}
+		})
+	}
+}
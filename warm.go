@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/DaikonSushi/bot-platform/pkg/pluginsdk"
+)
+
+// warmEntryDelimiter separates multi-line entries in a warm file
+// Without it, every non-empty line is a separate entry
+const warmEntryDelimiter = "---"
+
+// handleWarm handles the analyzewarm admin command
+// It analyzes known error logs from a file to pre-populate the result cache
+func (p *LogAnalyzerPlugin) handleWarm(bot *pluginsdk.BotClient, args []string, msg *pluginsdk.Message) {
+	if !p.isAdmin(msg.UserID) {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.admin_only")))
+		return
+	}
+	if p.cfg().cache == nil {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.cache_disabled")))
+		return
+	}
+	if len(args) != 1 {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("usage.warm")))
+		return
+	}
+
+	entries, err := p.readWarmFile(args[0])
+	if err != nil {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.warm_read", err)))
+		return
+	}
+	if len(entries) == 0 {
+		bot.Reply(msg, pluginsdk.Text(p.msgf("err.warm_empty")))
+		return
+	}
+
+	// Entries go through the same normalization as /analyze so their cache keys match
+	skipped := 0
+	for _, entry := range entries {
+		var opts AnalyzeOptions
+		prepared, err := p.prepareLog(&opts, entry)
+		if err != nil {
+			p.logf("warn", "Skipping warm entry: %v", err)
+			skipped++
+			continue
+		}
+		task := p.createTask(msg, opts)
+		task.silent = true
+		task.InputTruncated = prepared.truncated
+		task.LogFormat = prepared.format
+		task.logContent = prepared.content
+		p.startAnalysis(task, prepared.content, msg)
+	}
+
+	reply := p.msgf("warm.started", len(entries)-skipped)
+	if skipped > 0 {
+		reply += "\n" + p.msgf("warm.skipped", skipped)
+	}
+	bot.Reply(msg, pluginsdk.Text(reply))
+}
+
+// readWarmFile reads warm entries from a file inside SharedDataPath
+func (p *LogAnalyzerPlugin) readWarmFile(name string) ([]string, error) {
+	base, err := filepath.Abs(p.cfg().SharedDataPath)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(base, name)
+	if rel, err := filepath.Rel(base, path); err != nil || strings.HasPrefix(rel, "..") {
+		return nil, fmt.Errorf("file must be inside %s", base)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return splitWarmEntries(string(data)), nil
+}
+
+// splitWarmEntries splits warm file content into log entries
+func splitWarmEntries(content string) []string {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+
+	delimited := false
+	for _, line := range lines {
+		if strings.TrimSpace(line) == warmEntryDelimiter {
+			delimited = true
+			break
+		}
+	}
+
+	var entries []string
+	if !delimited {
+		for _, line := range lines {
+			if line = strings.TrimSpace(line); line != "" {
+				entries = append(entries, line)
+			}
+		}
+		return entries
+	}
+
+	var current []string
+	flush := func() {
+		if entry := strings.TrimSpace(strings.Join(current, "\n")); entry != "" {
+			entries = append(entries, entry)
+		}
+		current = nil
+	}
+	for _, line := range lines {
+		if strings.TrimSpace(line) == warmEntryDelimiter {
+			flush()
+			continue
+		}
+		current = append(current, line)
+	}
+	flush()
+	return entries
+}
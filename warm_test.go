@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DaikonSushi/bot-platform/pkg/pluginsdk"
+)
+
+func TestSplitWarmEntries(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"one per line", "ERROR a\n\n  ERROR b  \r\n", []string{"ERROR a", "ERROR b"}},
+		{"delimited", "ERROR a\n\tat x\n---\n\n---\nERROR b\n", []string{"ERROR a\n\tat x", "ERROR b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitWarmEntries(tt.content)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("splitWarmEntries = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWarmedEntriesHitCache(t *testing.T) {
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	cli := filepath.Join(dir, "knot-cli")
+	script := "#!/bin/sh\necho run >> " + calls + "\necho 'Root cause: disk full'\n"
+	if err := os.WriteFile(cli, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "known.txt"), []byte("ERROR disk full\nERROR pool exhausted\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Mode = "direct"
+	cfg.KnotCLIPath = cli
+	cfg.SharedDataPath = dir
+	cfg.AdminUserIDs = []int64{1}
+	cfg.CacheTTLMinutes = 60
+	p, bot := newTestPlugin(cfg)
+	cache := p.cfg().cache
+
+	msg := &pluginsdk.Message{Type: "private", UserID: 1}
+	p.handleWarm(p.bot, []string{"known.txt"}, msg)
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		cache.mu.Lock()
+		n := cache.order.Len()
+		cache.mu.Unlock()
+		if n == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("cache has %d entries after warming, want 2", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	task := p.createTask(msg, AnalyzeOptions{})
+	p.runAnalysis(task, "ERROR pool exhausted", msg)
+
+	if !task.Cached || task.Status != "completed" {
+		t.Errorf("task = %s cached=%v, want a completed cache hit", task.Status, task.Cached)
+	}
+	if data, _ := os.ReadFile(calls); strings.Count(string(data), "run") != 2 {
+		t.Errorf("knot-cli ran %d times, want 2 (warming only)", strings.Count(string(data), "run"))
+	}
+
+	sent := bot.sent()
+	last := sent[len(sent)-1].text
+	if !strings.Contains(last, "(cached)") {
+		t.Errorf("last reply = %q, want the cached result", last)
+	}
+	for _, m := range sent[:len(sent)-1] {
+		if strings.Contains(m.text, "Analysis Completed") {
+			t.Errorf("warming posted a result: %q", m.text)
+		}
+	}
+}
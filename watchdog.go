@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+// errTaskStuck is reported for running tasks reclaimed by the watchdog
+var errTaskStuck = errors.New("task exceeded expected runtime, marking failed")
+
+// runWatchdog periodically reclaims tasks stuck in "running" state
+// This is a safety net for missed completions, separate from the per-task timeout
+func (p *LogAnalyzerPlugin) runWatchdog() {
+	if p.cfg().WatchdogIntervalSec <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(p.cfg().WatchdogIntervalSec) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case now := <-ticker.C:
+			if n := p.reclaimStuckTasks(now); n > 0 {
+				p.logf("warn", "Watchdog reclaimed %d stuck task(s)", n)
+			}
+		}
+	}
+}
+
+// reclaimStuckTasks fails running tasks that exceeded their timeout + grace, stops them and
+// frees their slots right away; release is guarded so the goroutine's own release is a no-op
+func (p *LogAnalyzerPlugin) reclaimStuckTasks(now time.Time) int {
+	p.taskMutex.RLock()
+	var stuck []*TaskStatus
+	for _, task := range p.tasks {
+		limit := time.Duration(p.taskTimeout(task)+p.cfg().WatchdogGraceSec) * time.Second
+		if task.Status == "running" && now.Sub(task.RunStartTime) > limit {
+			stuck = append(stuck, task)
+		}
+	}
+	p.taskMutex.RUnlock()
+
+	for _, task := range stuck {
+		p.taskLogf("warn", task.ID, "Watchdog: task running for %s, marking failed", now.Sub(task.RunStartTime).Round(time.Second))
+		p.completeTask(task, "", errTaskStuck, task.msg)
+
+		p.taskMutex.RLock()
+		cancel, release, proxyURL := task.cancel, task.release, task.proxyURL
+		p.taskMutex.RUnlock()
+		if cancel != nil {
+			cancel()
+		}
+		if proxyURL != "" {
+			go p.cancelProxyTask(proxyURL, task.ID)
+		}
+		if release != nil {
+			release()
+		}
+	}
+
+	return len(stuck)
+}
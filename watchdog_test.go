@@ -0,0 +1,84 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DaikonSushi/bot-platform/pkg/pluginsdk"
+)
+
+func TestReclaimStuckTasks(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Timeout = 60
+	cfg.WatchdogGraceSec = 30
+	cfg.MaxConcurrent = 1
+	p, bot := newTestPlugin(cfg)
+
+	// The stuck task holds the only slot, released once like runAnalysis does
+	sem := p.globalSemaphore()
+	sem <- struct{}{}
+	var releaseOnce sync.Once
+	releases := 0
+	release := func() {
+		releaseOnce.Do(func() {
+			releases++
+			<-sem
+		})
+	}
+
+	now := time.Now()
+	stuck := &TaskStatus{
+		config:       p.cfg(),
+		ID:           "STUCK",
+		Status:       "running",
+		StartTime:    now.Add(-3 * time.Minute),
+		RunStartTime: now.Add(-91 * time.Second),
+		UserID:       1,
+		msg:          &pluginsdk.Message{Type: "private", UserID: 1},
+		release:      release,
+	}
+	healthy := &TaskStatus{
+		config:       p.cfg(),
+		ID:           "HEALTHY",
+		Status:       "running",
+		StartTime:    now.Add(-time.Minute),
+		RunStartTime: now.Add(-time.Minute),
+		msg:          &pluginsdk.Message{Type: "private", UserID: 2},
+		release:      func() { t.Error("healthy task was released") },
+	}
+	p.tasks[stuck.ID] = stuck
+	p.tasks[healthy.ID] = healthy
+
+	if n := p.reclaimStuckTasks(now); n != 1 {
+		t.Fatalf("reclaimStuckTasks = %d, want 1", n)
+	}
+	if stuck.Status != "failed" || stuck.Error != errTaskStuck.Error() {
+		t.Errorf("stuck task = %s %q, want failed %q", stuck.Status, stuck.Error, errTaskStuck)
+	}
+	// The slot is free for the next task without waiting for the stuck goroutine
+	select {
+	case sem <- struct{}{}:
+	default:
+		t.Fatal("stuck task slot was not released on reclaim")
+	}
+	// The stuck goroutine's own deferred release must not free the new holder's slot
+	release()
+	if releases != 1 || len(sem) != 1 {
+		t.Errorf("slot released %d times with %d held, want 1 release and the new slot kept", releases, len(sem))
+	}
+	if healthy.Status != "running" {
+		t.Errorf("healthy task status = %s, want running", healthy.Status)
+	}
+
+	sent := bot.sent()
+	if len(sent) != 1 || sent[0].userID != 1 || !strings.Contains(sent[0].text, errTaskStuck.Error()) {
+		t.Errorf("sent = %+v, want one failure reply to user 1", sent)
+	}
+
+	// A second scan finds nothing left to reclaim
+	if n := p.reclaimStuckTasks(now); n != 0 {
+		t.Errorf("second reclaimStuckTasks = %d, want 0", n)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// webhookExcerptBytes bounds the result excerpt sent to the completion webhook
+const webhookExcerptBytes = 500
+
+// webhookRetryDelay is the pause before the single webhook retry
+const webhookRetryDelay = 2 * time.Second
+
+// completionWebhookPayload is posted to WebhookURL when a task completes or fails
+type completionWebhookPayload struct {
+	TaskStatus
+	OutputPath string `json:"output_path,omitempty"`
+	Excerpt    string `json:"excerpt,omitempty"`
+}
+
+// notifyCompletion posts the finished task to WebhookURL in the background
+func (p *LogAnalyzerPlugin) notifyCompletion(task *TaskStatus, outputPath, result string) {
+	if task.config.WebhookURL == "" || task.silent {
+		return
+	}
+
+	p.taskMutex.RLock()
+	payload := completionWebhookPayload{
+		TaskStatus: *task,
+		OutputPath: outputPath,
+		Excerpt:    truncateUTF8(task.config.redactHosts(result), webhookExcerptBytes),
+	}
+	p.taskMutex.RUnlock()
+
+	go p.postCompletionWebhook(payload)
+}
+
+// postCompletionWebhook sends the payload, retrying once; failures are only logged
+func (p *LogAnalyzerPlugin) postCompletionWebhook(payload completionWebhookPayload) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		p.taskLogf("warn", payload.ID, "Failed to encode webhook payload: %v", err)
+		return
+	}
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		if err = p.sendCompletionWebhook(payload.ID, body); err == nil {
+			return
+		}
+		if attempt == 1 {
+			time.Sleep(webhookRetryDelay)
+		}
+	}
+	p.taskLogf("warn", payload.ID, "Completion webhook failed: %v", err)
+}
+
+// sendCompletionWebhook makes a single webhook request
+func (p *LogAnalyzerPlugin) sendCompletionWebhook(taskID string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, p.cfg().WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Task-ID", taskID)
+
+	resp, err := p.cfg().httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
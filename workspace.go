@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// Workspace isolation modes for direct-mode tasks
+const (
+	workspaceShared   = "shared"   // every task uses WorkspacePath and the default temp dir
+	workspaceTempDir  = "tempdir"  // shared WorkspacePath, private working and temp dir
+	workspaceWorktree = "worktree" // private git worktree of WorkspacePath (detached HEAD)
+	workspaceCopy     = "copy"     // private copy of WorkspacePath
+)
+
+// taskWorkspace is the working directory set up for one direct-mode task
+type taskWorkspace struct {
+	path   string // passed to knot-cli with -w
+	tmpDir string // working and temp directory of the process; empty when shared
+	remove func() // cleans up after the task
+}
+
+// prepareWorkspace sets up the task's working directory per WorkspaceIsolation
+// Callers must call remove once knot-cli has exited
+func (p *LogAnalyzerPlugin) prepareWorkspace(task *TaskStatus) (*taskWorkspace, error) {
+	ws := &taskWorkspace{path: task.config.WorkspacePath, remove: func() {}}
+	if task.config.WorkspaceIsolation == workspaceShared || task.config.WorkspaceIsolation == "" {
+		return ws, nil
+	}
+
+	tmpDir, err := os.MkdirTemp("", "loganalyzer-"+task.ID+"-")
+	if err != nil {
+		return nil, err
+	}
+	ws.tmpDir = tmpDir
+	ws.remove = func() { os.RemoveAll(tmpDir) }
+
+	target := filepath.Join(tmpDir, "workspace")
+	switch task.config.WorkspaceIsolation {
+	case workspaceTempDir:
+		return ws, nil
+	case workspaceWorktree:
+		out, err := exec.Command("git", "-C", task.config.WorkspacePath, "worktree", "add", "--detach", target).CombinedOutput()
+		if err != nil {
+			ws.remove()
+			return nil, fmt.Errorf("git worktree add failed: %v: %s", err, out)
+		}
+		ws.remove = func() {
+			exec.Command("git", "-C", task.config.WorkspacePath, "worktree", "remove", "--force", target).Run()
+			os.RemoveAll(tmpDir)
+		}
+	case workspaceCopy:
+		if err := copyTree(task.config.WorkspacePath, target); err != nil {
+			ws.remove()
+			return nil, fmt.Errorf("failed to copy workspace: %v", err)
+		}
+	default:
+		ws.remove()
+		return nil, fmt.Errorf("unknown workspace isolation %q", task.config.WorkspaceIsolation)
+	}
+	ws.path = target
+	return ws, nil
+}
+
+// apply runs cmd inside the task's private directory, if it has one
+func (ws *taskWorkspace) apply(cmd *exec.Cmd) {
+	if ws.tmpDir == "" {
+		return
+	}
+	cmd.Dir = ws.tmpDir
+	cmd.Env = append(os.Environ(), "TMPDIR="+ws.tmpDir)
+}
+
+// copyTree copies a directory tree, recreating symlinks rather than following them
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		}
+		return nil // sockets, devices and pipes are skipped
+	})
+}
+
+// copyFile copies one regular file with the given permissions
+func copyFile(src, dst string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}